@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+const adaptiveSource = "Adaptive"
+
+// weightedSampler picks indexes at random in proportion to their weights
+type weightedSampler struct {
+	cumulative []float64 // the running total of the weights, so each index owns a slice of the range
+}
+
+func newWeightedSampler(weights []float64) *weightedSampler {
+	ws := &weightedSampler{cumulative: make([]float64, len(weights))}
+	total := 0.0
+	for i, w := range weights {
+		total += max(w, 0)
+		ws.cumulative[i] = total
+	}
+	return ws
+}
+
+func (ws *weightedSampler) next() int {
+	total := ws.cumulative[len(ws.cumulative)-1]
+	if total == 0 {
+		return rand.Intn(len(ws.cumulative))
+	}
+	// the first index whose running total passes a random point in the range
+	point := rand.Float64() * total
+	return sort.Search(len(ws.cumulative), func(i int) bool {
+		return ws.cumulative[i] > point
+	})
+}
+
+// adaptiveWords is the word source for adaptive rounds, picking words from a language pack
+// more often the worse the user has done on their characters and pairs of characters
+type adaptiveWords struct {
+	lang    *language
+	sampler *weightedSampler
+}
+
+// weights every word in a language pack by the weakness of its characters and pairs in the stats
+func newAdaptiveWords(lang *language, ks *keyStats) *adaptiveWords {
+	overall := ks.overallLatency()
+	weights := make([]float64, len(lang.Words))
+	for i, word := range lang.Words {
+		chars := splitGraphemes(word)
+		weight := 1.0
+		for j, char := range chars {
+			weight += ks.Chars[char].weakness(overall)
+			if j > 0 {
+				weight += ks.Bigrams[chars[j-1]+char].weakness(overall)
+			}
+		}
+		weights[i] = weight
+	}
+	return &adaptiveWords{lang: lang, sampler: newWeightedSampler(weights)}
+}
+
+func (a *adaptiveWords) Name() string {
+	return adaptiveSource
+}
+
+func (a *adaptiveWords) Description() string {
+	return "Words from the language picked, favouring the keys you find hardest"
+}
+
+func (a *adaptiveWords) NextWord() string {
+	return a.lang.Words[a.sampler.next()]
+}
+
+func (a *adaptiveWords) NextChunk(n int) []string {
+	return nextChunk(a, n)
+}
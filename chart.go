@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	chartHeight     = 6  // rows of the chart, not counting its axis
+	chartWidth      = 60 // the most columns the chart takes up
+	chartStretch    = 4  // the most columns one second can be stretched over in a short round
+	chartNetPoint   = "•"
+	chartRawPoint   = "·"
+	chartErrorPoint = "x"
+)
+
+// wpmSample is how a round was going at the end of one of its seconds
+type wpmSample struct {
+	Second int     `json:"second"`
+	WPM    float64 `json:"wpm"` // net words per minute over the whole round so far
+	Raw    float64 `json:"raw"` // words per minute typed in just this second, right or wrong
+	Errors int     `json:"errors"`
+}
+
+// takes a sample for each whole second that has gone by since the last one, called on every tick
+func (t *typing) sample() {
+	if !t.time.isActive() {
+		return
+	}
+	t.sampleSeconds()
+}
+
+// takes a sample at the end of every whole second not sampled yet
+func (t *typing) sampleSeconds() {
+	for second := len(t.samples) + 1; float64(second) <= t.time.elapsed(); second++ {
+		t.takeSample(second, float64(second))
+	}
+}
+
+// samples the round as it is now, at the given point in it. the final sample of a round
+// is usually taken part way through a second so elapsed doesn't have to be a whole number
+func (t *typing) takeSample(second int, elapsed float64) {
+	correct, incorrect, extra := t.charCounts()
+	typed := correct + incorrect + extra
+	errors := t.keyPresses() - t.correctKeyPresses()
+
+	s := wpmSample{Second: second, Errors: max(0, errors-t.sampledErrors)}
+	if elapsed > 0 {
+		s.WPM = float64(correct) / 5 / (elapsed / 60)
+	}
+	if span := elapsed - float64(second-1); span > 0 {
+		s.Raw = float64(typed-t.sampledTyped) / 5 / (span / 60)
+	}
+	t.sampledTyped, t.sampledErrors = typed, errors
+	t.samples = append(t.samples, s)
+}
+
+// takes a last sample for the part of a second the round finished in
+func (t *typing) finishSamples() {
+	// catch up on any whole seconds the ticks didn't get to first
+	t.sampleSeconds()
+	elapsed := t.time.elapsed()
+	if float64(len(t.samples)) < elapsed {
+		t.takeSample(len(t.samples)+1, elapsed)
+	}
+}
+
+// renders net and raw WPM over the seconds of a round as a line chart, with a mark under
+// each second where mistakes were made
+func viewChart(samples []wpmSample, designStyles colourTheme) string {
+	if len(samples) < 2 {
+		return ""
+	}
+	top := 0.0
+	for _, s := range samples {
+		top = max(top, s.WPM, s.Raw)
+	}
+	// round the top of the scale up to the next 10 so the label is tidy
+	top = math.Max(10, math.Ceil(top/10)*10)
+
+	width := min(chartWidth, len(samples)*chartStretch)
+	// which sample each column shows
+	columns := make([]int, width)
+	for c := range columns {
+		columns[c] = c * len(samples) / width
+	}
+	row := func(wpm float64) int {
+		return int(math.Round(wpm / top * float64(chartHeight-1)))
+	}
+
+	res := ""
+	for r := chartHeight - 1; r >= 0; r-- {
+		label := ""
+		if r == chartHeight-1 {
+			label = fmt.Sprint(top)
+		} else if r == 0 {
+			label = "0"
+		}
+		line := designStyles.normalText.Render(fmt.Sprintf("%4s ┤", label))
+		for _, i := range columns {
+			switch r {
+			case row(samples[i].WPM):
+				line += designStyles.typeTextCorrect.Render(chartNetPoint)
+			case row(samples[i].Raw):
+				line += designStyles.typeTextDefault.Render(chartRawPoint)
+			default:
+				line += " "
+			}
+		}
+		res += line + "\n"
+	}
+
+	res += designStyles.normalText.Render("     └"+strings.Repeat("─", width)) + "\n      "
+	for c, i := range columns {
+		// mark errors once, on the first column of their second
+		if samples[i].Errors > 0 && (c == 0 || columns[c-1] != i) {
+			res += designStyles.typeTextIncorrect.Render(chartErrorPoint)
+		} else {
+			res += " "
+		}
+	}
+	res += "\n" + designStyles.normalText.Render(fmt.Sprintf("      %s net  %s raw  %s errors  over %d s", chartNetPoint, chartRawPoint, chartErrorPoint, len(samples)))
+	return res
+}
@@ -9,10 +9,14 @@ import (
 )
 
 const (
-	configDirName  = "typingTester"
-	configFilename = "config.json"
+	configDirName    = "typingTester"
+	configFilename   = "config.json"
+	settingsFilename = "settings.json"
 )
 
+// savedSettings is the on-disk form of the settings tab, mapping each block title to its selected option
+type savedSettings map[string]string
+
 type themeConfig struct {
 	Name               string `json:"name"`
 	BorderActiveColor  string `json:"border_active_color"`
@@ -66,6 +70,73 @@ func (m model) loadConfig() ([]colourTheme, error) {
 
 }
 
+// returns the path of a file or directory inside the app's config directory
+func configPath(elem ...string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{configDir, configDirName}, elem...)...), nil
+}
+
+// writes a file inside the app's config directory, creating any directories it's in
+func writeConfigFile(name string, data []byte) error {
+	path, err := makeConfigPath(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// adds to the end of a file inside the app's config directory, creating it if need be
+func appendConfigFile(name string, data []byte) error {
+	path, err := makeConfigPath(name)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+// returns the path of a file inside the app's config directory, making sure the directories
+// it's in exist
+func makeConfigPath(name string) (string, error) {
+	path, err := configPath(name)
+	if err != nil {
+		return "", err
+	}
+	return path, os.MkdirAll(filepath.Dir(path), 0755)
+}
+
+func loadSettings() (savedSettings, error) {
+	path, err := configPath(settingsFilename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	saved := savedSettings{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func saveSettings(saved savedSettings) error {
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(settingsFilename, data)
+}
+
 func (m model) createConfig(configDir string) ([]colourTheme, error) {
 	// create directory if doesn't exist
 	appConfigDir := filepath.Join(configDir, configDirName)
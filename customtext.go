@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// typographic characters which are awkward or impossible to type, and what to type instead
+var textReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+	"“", "\"", "”", "\"", "„", "\"", "‟", "\"",
+	"–", "-", "—", "-",
+	"…", "...",
+)
+
+// reads the text to practise from a file, or from stdin when it has been piped in
+// returns an empty string when nothing was given, and an error when a file or stdin was given
+// with nothing to type in it
+func readCustomText(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		text := normaliseText(string(data))
+		if text == "" {
+			return "", fmt.Errorf("%s has no text to type", path)
+		}
+		return text, nil
+	}
+
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return "", err
+	}
+	if stat.Mode()&os.ModeCharDevice != 0 {
+		// stdin is a terminal so nothing was piped in
+		return "", nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	text := normaliseText(string(data))
+	if text == "" {
+		return "", fmt.Errorf("the text piped in has nothing to type")
+	}
+	return text, nil
+}
+
+// makes text typeable - swaps smart quotes and dashes for plain ones and collapses
+// tabs, newlines and trailing whitespace into single spaces so the view can wrap it
+func normaliseText(text string) string {
+	text = textReplacer.Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+)
+
+const lastRoundFilename = "last_round.json"
+
+// what a key press did, recorded with each keyEvent
+const (
+	outcomeCorrect   = "correct"
+	outcomeIncorrect = "incorrect"
+	outcomeExtra     = "extra"   // typed past the end of a word
+	outcomeSkip      = "skip"    // space part way through a word, skipping the rest of it
+	outcomeDelete    = "delete"  // backspace or deleting a word
+	outcomeIgnored   = "ignored" // the key didn't change anything, e.g. backspace at the start
+)
+
+// keyEvent is one key press in a round
+type keyEvent struct {
+	OffsetMs int64  `json:"offset_ms"` // milliseconds since the round was set up
+	Key      string `json:"key"`
+	Position int    `json:"position"` // where the cursor was in the whole round's text
+	Expected string `json:"expected"` // the character at the cursor, empty past the end of the text
+	Outcome  string `json:"outcome"`
+}
+
+// roundLog is everything recorded about a round, saved alongside its result
+type roundLog struct {
+	Result  roundResult `json:"result"`
+	Text    string      `json:"text"` // all the text generated for the round, or typed in zen mode
+	Events  []keyEvent  `json:"events"`
+	Samples []wpmSample `json:"samples"`
+
+	StopOnError string `json:"stop_on_error,omitempty"` // needed to replay the keys as they were typed
+}
+
+// passes a key press on to the round and records it, along with what it did, in the event log
+func (t *typing) pressKey(key string) {
+	offset := time.Since(t.created)
+	before, extras := t.position, t.extraKeys
+	misses := t.keyPresses() - t.correctKeyPresses()
+	event := keyEvent{OffsetMs: offset.Milliseconds(), Key: key, Position: t.trimmedChars + before}
+	if before < len(t.content) {
+		event.Expected = t.content[before]
+	}
+
+	t.updateTypingTab(key)
+
+	switch {
+	case key == "backspace" || key == "ctrl+h" || key == "alt+backspace" || key == "ctrl+w":
+		event.Outcome = outcomeIgnored
+		if t.position < before || t.extraKeys < extras {
+			event.Outcome = outcomeDelete
+		}
+	case t.extraKeys > extras:
+		event.Outcome = outcomeExtra
+	case t.position > before && t.characterColours[before] == missedKey:
+		// skipped characters count as misses too, so check for a skip first
+		event.Outcome = outcomeSkip
+	case t.keyPresses()-t.correctKeyPresses() > misses:
+		event.Outcome = outcomeIncorrect
+	case t.position > before:
+		event.Outcome = outcomeCorrect
+	default:
+		event.Outcome = outcomeIgnored
+	}
+	t.events = append(t.events, event)
+}
+
+// returns the round's log, with zen rounds' text being what was typed
+func (t *typing) roundLog() roundLog {
+	text := strings.Join(t.generated, "")
+	if t.gameMode == gameModeZen {
+		text = strings.Join(t.content, "")
+	}
+	return roundLog{Result: t.result, Text: text, Events: t.events, Samples: t.samples, StopOnError: t.stopOnError}
+}
+
+// returns when in the log the round's timer started, which is on the first key that typed something
+func (log roundLog) timerStart() time.Duration {
+	for _, event := range log.Events {
+		if event.Outcome != outcomeIgnored && event.Outcome != outcomeDelete {
+			return time.Duration(event.OffsetMs) * time.Millisecond
+		}
+	}
+	return 0
+}
+
+// reads a round log saved by saveRoundLog
+func loadRoundLog(path string) (roundLog, error) {
+	var log roundLog
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return log, err
+	}
+	err = json.Unmarshal(data, &log)
+	return log, err
+}
+
+// writes the log of the last round played over the previous one
+func saveRoundLog(log roundLog) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(lastRoundFilename, data)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ghostOff      = "Off"
+	ghostBest     = "Personal Best"
+	bestsDirName  = "bests" // holds the round log of the best round for each test
+	charsPerWord  = 5
+	ghostNameBest = "personal best"
+)
+
+// ghost is a second caret that races the user through a round, either at a steady target
+// pace or retracing the cursor of their best round of the same test
+type ghost struct {
+	name  string
+	wpm   float64     // the target pace, 0 when retracing a recorded round
+	steps []ghostStep // where the recorded round's cursor went, in order
+}
+
+// ghostStep is where a recorded round's cursor moved to after a key press
+type ghostStep struct {
+	at       float64 // seconds since the round's timer started
+	position int
+}
+
+// builds the ghost picked in the settings for a round, or nil for none. a personal best ghost
+// is also nil when the round's test hasn't been completed yet, and for a quote, snippet or
+// custom text unless the best round was on the same text
+func newGhost(option string, t *typing) *ghost {
+	switch {
+	case option == ghostOff || t.gameMode == gameModeZen:
+		// zen has no text to race along
+		return nil
+	case option == ghostBest:
+		log, err := loadBestLog(t.bestKey())
+		if err != nil {
+			return nil
+		}
+		switch t.gameMode {
+		case gameModeQuote, gameModeCode, gameModeCustom:
+			if log.Text != strings.Join(t.generated, "") {
+				return nil
+			}
+		}
+		return newBestGhost(log)
+	}
+	wpm, err := strconv.Atoi(option)
+	if err != nil {
+		return nil
+	}
+	return &ghost{name: fmt.Sprintf("%d WPM", wpm), wpm: float64(wpm)}
+}
+
+// builds a ghost which retraces the cursor of a recorded round
+func newBestGhost(log roundLog) *ghost {
+	g := &ghost{name: ghostNameBest}
+	start := log.timerStart()
+	for i, event := range log.Events {
+		at := (time.Duration(event.OffsetMs)*time.Millisecond - start).Seconds()
+		if at < 0 {
+			continue
+		}
+		// the cursor was where the next key was pressed, and the last key moves it on one
+		position := event.Position + 1
+		if i+1 < len(log.Events) {
+			position = log.Events[i+1].Position
+		}
+		g.steps = append(g.steps, ghostStep{at: at, position: position})
+	}
+	return g
+}
+
+// returns where in the whole round's text the ghost is after the given seconds
+func (g *ghost) position(elapsed float64) int {
+	if g.wpm > 0 {
+		return int(elapsed / 60 * g.wpm * charsPerWord)
+	}
+	i := sort.Search(len(g.steps), func(i int) bool { return g.steps[i].at > elapsed })
+	if i == 0 {
+		return 0
+	}
+	return g.steps[i-1].position
+}
+
+// returns how many seconds the ghost takes to reach a position, false if it never does
+func (g *ghost) reaches(position int) (float64, bool) {
+	if g.wpm > 0 {
+		return float64(position) / charsPerWord / g.wpm * 60, true
+	}
+	for _, step := range g.steps {
+		if step.position >= position {
+			return step.at, true
+		}
+	}
+	return 0, false
+}
+
+// returns where the ghost's caret is in the content, negative when there's no ghost or it's on
+// text a countdown round has dropped
+func (t typing) ghostPosition() int {
+	if t.ghost == nil || len(t.content) == 0 {
+		return -1
+	}
+	return min(t.ghost.position(t.time.elapsed())-t.trimmedChars, len(t.content)-1)
+}
+
+// describes how far ahead or behind the ghost a round finished, empty without a ghost. a round
+// that got to the end of its text is compared by time, and any other by how far it got
+func (t *typing) ghostLead() string {
+	if t.ghost == nil {
+		return ""
+	}
+	elapsed := t.time.elapsed()
+	if t.gameMode != gameModeCountdown && !t.time.isFailed() {
+		if at, ok := t.ghost.reaches(t.trimmedChars + len(t.content) - 1); ok {
+			return t.ghost.describeLead(at-elapsed, fmt.Sprintf("%.1f s", math.Abs(at-elapsed)))
+		}
+	}
+	chars := t.trimmedChars + t.position - t.ghost.position(elapsed)
+	amount := fmt.Sprintf("%d characters", max(chars, -chars))
+	if chars == 1 || chars == -1 {
+		amount = "1 character"
+	}
+	return t.ghost.describeLead(float64(chars), amount)
+}
+
+// describes a lead over the ghost, a negative one being behind it
+func (g *ghost) describeLead(lead float64, amount string) string {
+	if lead >= 0 {
+		return fmt.Sprintf("%s ahead of the %s ghost", amount, g.name)
+	}
+	return fmt.Sprintf("%s behind the %s ghost", amount, g.name)
+}
+
+// describes the round's test for telling its best round apart from other tests', e.g.
+// "quote, Quotes, Short"
+func (t *typing) bestKey() string {
+	key := t.testResult().testType()
+	if t.gameMode == gameModeQuote {
+		key += ", " + t.quoteLength
+	}
+	return key
+}
+
+// returns the name of the best round log for a test in the config directory, named after its
+// key with anything but letters and digits turned into dashes
+func bestLogName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, key)
+	return filepath.Join(bestsDirName, strings.Join(strings.Fields(name), "-")+".json")
+}
+
+// reads the log of the best round played of a test
+func loadBestLog(key string) (roundLog, error) {
+	path, err := configPath(bestLogName(key))
+	if err != nil {
+		return roundLog{}, err
+	}
+	return loadRoundLog(path)
+}
+
+// keeps a round's log if it's the best completed round of its test so far
+func saveBestLog(key string, log roundLog) error {
+	result := log.Result
+	if result.Failed != "" || result.Mode == gameModeZen {
+		return nil
+	}
+	if best, err := loadBestLog(key); err == nil && best.Result.WPM >= result.WPM {
+		return nil
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(bestLogName(key), data)
+}
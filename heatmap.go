@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+const heatmapSteps = 5 // how many shades the legend shows
+
+// the rows of a QWERTY keyboard and how far each is indented, in cells
+var (
+	keyboardRows   = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+	keyboardIndent = []int{0, 2, 3, 5}
+)
+
+// the key each shifted character is typed on
+var shiftedKeys = map[string]string{
+	"~": "`", "!": "1", "@": "2", "#": "3", "$": "4", "%": "5", "^": "6", "&": "7", "*": "8",
+	"(": "9", ")": "0", "_": "-", "+": "=", "{": "[", "}": "]", "|": "\\", ":": ";", "\"": "'",
+	"<": ",", ">": ".", "?": "/",
+}
+
+// the heatmaps the stats tab can show, changed with ↑ and ↓
+var heatmapViews = []struct {
+	title     string
+	slowness  bool // shade by how slow keys are rather than how often they're wrong
+	lastRound bool
+}{
+	{"Errors, last round", false, true},
+	{"Errors, all time", false, false},
+	{"Slowness, last round", true, true},
+	{"Slowness, all time", true, false},
+}
+
+// returns the stats of the characters typed in a logged round, timing each key from the one before
+func (log roundLog) keyStats() *keyStats {
+	ks := &keyStats{}
+	var last int64
+	for i, event := range log.Events {
+		var latency time.Duration
+		if i > 0 {
+			latency = time.Duration(event.OffsetMs-last) * time.Millisecond
+		}
+		last = event.OffsetMs
+		if event.Outcome == outcomeCorrect || event.Outcome == outcomeIncorrect {
+			ks.record(event.Expected, "", event.Outcome == outcomeCorrect, latency)
+		}
+	}
+	return ks
+}
+
+// sums the stats of every character typed on each key, so upper and lower case and shifted
+// symbols count towards the key they're on
+func (ks *keyStats) byKey() map[string]*keyStat {
+	keys := map[string]*keyStat{}
+	for char, s := range ks.Chars {
+		key := strings.ToLower(char)
+		if base, ok := shiftedKeys[char]; ok {
+			key = base
+		}
+		if keys[key] == nil {
+			keys[key] = &keyStat{}
+		}
+		keys[key].Presses += s.Presses
+		keys[key].Errors += s.Errors
+		keys[key].Timed += s.Timed
+		keys[key].LatencyMs += s.LatencyMs
+	}
+	return keys
+}
+
+// scores every key from 0 to 1 by its error rate or average latency, relative to the best
+// and worst keys so the whole range of shades gets used
+func heatScores(keys map[string]*keyStat, slowness bool) map[string]float64 {
+	values := map[string]float64{}
+	for key, s := range keys {
+		switch {
+		case slowness && s.Timed > 0:
+			values[key] = s.averageLatency()
+		case !slowness && s.Presses > 0:
+			values[key] = float64(s.Errors) / float64(s.Presses)
+		}
+	}
+	low, high := math.Inf(1), 0.0
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+	if !slowness {
+		// a key that's never typed wrong always gets the best shade
+		low = 0
+	}
+	scores := map[string]float64{}
+	for key, v := range values {
+		scores[key] = 0
+		if high > low {
+			scores[key] = (v - low) / (high - low)
+		}
+	}
+	return scores
+}
+
+// returns a style shading a key from the theme's correct colour at 0 to its incorrect colour at 1
+func heatStyle(score float64, designStyles colourTheme) lipgloss.Style {
+	good, goodOk := styleColour(designStyles.typeTextCorrect)
+	bad, badOk := styleColour(designStyles.typeTextIncorrect)
+	if !goodOk || !badOk {
+		// colours that can't be blended just split the keys into good and bad
+		if score < 0.5 {
+			return designStyles.typeTextCorrect.Reverse(true)
+		}
+		return designStyles.typeTextIncorrect.Reverse(true)
+	}
+	blend := good.BlendLab(bad, score).Clamped()
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(blend.Hex())).Reverse(true)
+}
+
+// returns the foreground colour of a style, false if it hasn't one in hex
+func styleColour(style lipgloss.Style) (colorful.Color, bool) {
+	c, ok := style.GetForeground().(lipgloss.Color)
+	if !ok {
+		return colorful.Color{}, false
+	}
+	colour, err := colorful.Hex(string(c))
+	return colour, err == nil
+}
+
+// renders a keyboard with each key shaded by its score, keys with no score being left plain
+func viewKeyboard(scores map[string]float64, designStyles colourTheme) string {
+	cell := func(key string, label string) string {
+		score, ok := scores[key]
+		if !ok {
+			return designStyles.typeTextDefault.Render(label)
+		}
+		return heatStyle(score, designStyles).Render(label)
+	}
+	lines := []string{}
+	for i, row := range keyboardRows {
+		line := strings.Repeat(" ", keyboardIndent[i])
+		for _, key := range strings.Split(row, "") {
+			line += cell(key, " "+key+" ") + " "
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, strings.Repeat(" ", 14)+cell(" ", strings.Repeat(" ", 9)+"space"+strings.Repeat(" ", 9)))
+
+	legend := designStyles.normalText.Render("best ")
+	for step := 0; step < heatmapSteps; step++ {
+		legend += heatStyle(float64(step)/(heatmapSteps-1), designStyles).Render("  ")
+	}
+	lines = append(lines, "", legend+designStyles.normalText.Render(" worst"))
+
+	// the tab centres each line on its own so pad them to the same width to keep the keys lined up
+	width := 0
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+	for i, line := range lines {
+		lines[i] = line + strings.Repeat(" ", width-lipgloss.Width(line))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const historyFilename = "history.jsonl"
+
+// roundResult is the record kept of every finished round
+type roundResult struct {
+	Date        time.Time `json:"date"`
+	Mode        string    `json:"mode"`
+	Count       int       `json:"count,omitempty"` // seconds or words depending on the mode
+	Language    string    `json:"language"`
+	WPM         float64   `json:"wpm"` // net words per minute, only counting correct characters
+	RawWPM      float64   `json:"raw_wpm"`
+	Accuracy    float64   `json:"accuracy"`
+	Consistency float64   `json:"consistency"`
+	Time        float64   `json:"time"` // seconds typed for
+	Correct     int       `json:"correct"`
+	Incorrect   int       `json:"incorrect"`
+	Extra       int       `json:"extra"`
+	Failed      string    `json:"failed,omitempty"`     // why the round failed, empty if it was completed
+	Missed      int       `json:"missed,omitempty"`     // characters skipped by pressing space part way through a word
+	Confidence  string    `json:"confidence,omitempty"` // how far back backspace could go, empty for anywhere
+	Modifiers   []string  `json:"modifiers,omitempty"`
+	Ghost       string    `json:"ghost,omitempty"` // how far ahead or behind the ghost the round finished
+}
+
+// reads every result saved so far, oldest first
+func loadHistory() ([]roundResult, error) {
+	path, err := configPath(historyFilename)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	// the history is one JSON result per line so it can be appended to without rewriting it
+	var results []roundResult
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r roundResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		results = append(results, r)
+	}
+	return results, scanner.Err()
+}
+
+func appendHistory(r roundResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return appendConfigFile(historyFilename, append(data, '\n'))
+}
+
+// returns the results recorded in the given language
+func filterByLanguage(results []roundResult, language string) []roundResult {
+	res := []roundResult{}
+	for _, r := range results {
+		if r.Language == language {
+			res = append(res, r)
+		}
+	}
+	return res
+}
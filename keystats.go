@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	keyStatsFilename = "keystats.json"
+	maxKeyLatency    = 2 * time.Second // longer gaps are pauses rather than slow keys so aren't timed
+)
+
+// keyHistory is every key press recorded across rounds, loaded at startup and added to after each round
+var keyHistory = &keyStats{}
+
+// keyStat is how a character, or a pair of characters typed one after the other, has gone so far
+type keyStat struct {
+	Presses   int   `json:"presses"`
+	Errors    int   `json:"errors"`
+	Timed     int   `json:"timed"`      // presses which have a latency, the first of a round doesn't
+	LatencyMs int64 `json:"latency_ms"` // total time taken over the timed presses
+}
+
+// keyStats holds stats for each expected character and each pair of expected characters
+type keyStats struct {
+	Chars   map[string]*keyStat `json:"chars"`
+	Bigrams map[string]*keyStat `json:"bigrams"`
+}
+
+// records a key press against the character expected and the pair it finishes, if any.
+// latency is the time since the previous key press, 0 when there wasn't one
+func (ks *keyStats) record(char string, bigram string, correct bool, latency time.Duration) {
+	if ks.Chars == nil {
+		ks.Chars = map[string]*keyStat{}
+		ks.Bigrams = map[string]*keyStat{}
+	}
+	ks.Chars[char] = ks.Chars[char].with(correct, latency)
+	if bigram != "" {
+		ks.Bigrams[bigram] = ks.Bigrams[bigram].with(correct, latency)
+	}
+}
+
+func (s *keyStat) with(correct bool, latency time.Duration) *keyStat {
+	if s == nil {
+		s = &keyStat{}
+	}
+	s.Presses += 1
+	if !correct {
+		s.Errors += 1
+	}
+	if latency > 0 && latency <= maxKeyLatency {
+		s.Timed += 1
+		s.LatencyMs += latency.Milliseconds()
+	}
+	return s
+}
+
+// adds another set of stats, e.g. a finished round's, to these
+func (ks *keyStats) add(other *keyStats) {
+	if ks.Chars == nil {
+		ks.Chars = map[string]*keyStat{}
+		ks.Bigrams = map[string]*keyStat{}
+	}
+	merge := func(into map[string]*keyStat, from map[string]*keyStat) {
+		for key, s := range from {
+			if into[key] == nil {
+				into[key] = &keyStat{}
+			}
+			into[key].Presses += s.Presses
+			into[key].Errors += s.Errors
+			into[key].Timed += s.Timed
+			into[key].LatencyMs += s.LatencyMs
+		}
+	}
+	merge(ks.Chars, other.Chars)
+	merge(ks.Bigrams, other.Bigrams)
+}
+
+func (s *keyStat) averageLatency() float64 {
+	if s.Timed == 0 {
+		return 0
+	}
+	return float64(s.LatencyMs) / float64(s.Timed)
+}
+
+// returns the average latency over every timed character press
+func (ks *keyStats) overallLatency() float64 {
+	total, timed := int64(0), 0
+	for _, s := range ks.Chars {
+		total += s.LatencyMs
+		timed += s.Timed
+	}
+	if timed == 0 {
+		return 0
+	}
+	return float64(total) / float64(timed)
+}
+
+// scores how badly a key is typed, 0 for one typed perfectly at the average speed.
+// the error rate assumes a couple of correct presses so one early mistake doesn't dominate,
+// and being slower than the overall average latency adds to the score
+func (s *keyStat) weakness(overallLatency float64) float64 {
+	if s == nil {
+		return 0
+	}
+	score := float64(s.Errors) / float64(s.Presses+2) * 10
+	if overallLatency > 0 && s.Timed > 0 {
+		score += max(0, s.averageLatency()/overallLatency-1)
+	}
+	return score
+}
+
+// returns up to n characters with the highest weakness, worst first
+func (ks *keyStats) weakestKeys(n int) []string {
+	overall := ks.overallLatency()
+	keys := []string{}
+	for key, s := range ks.Chars {
+		if s.weakness(overall) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		wi, wj := ks.Chars[keys[i]].weakness(overall), ks.Chars[keys[j]].weakness(overall)
+		if wi != wj {
+			return wi > wj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[:min(n, len(keys))]
+}
+
+// reads the saved key stats, returning empty stats when there aren't any yet
+func loadKeyStats() (*keyStats, error) {
+	ks := &keyStats{}
+	path, err := configPath(keyStatsFilename)
+	if err != nil {
+		return ks, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ks, nil
+		}
+		return ks, err
+	}
+	if err := json.Unmarshal(data, ks); err != nil {
+		return &keyStats{}, err
+	}
+	return ks, nil
+}
+
+func (ks *keyStats) save() error {
+	data, err := json.Marshal(ks)
+	if err != nil {
+		return err
+	}
+	return writeConfigFile(keyStatsFilename, data)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const (
+	languageDirName = "languages"
+	defaultLanguage = "English 200"
+)
+
+//go:embed languages
+var languageFiles embed.FS
+
+var languages = parseLanguages()
+
+// language is a pack of words in one language. the embedded packs and the user's own
+// packs in the languages config directory share this JSON format
+type language struct {
+	Name  string   `json:"name"`
+	Words []string `json:"words"`
+}
+
+// languageWords is the built in word source, picking random words from a language pack
+type languageWords struct {
+	lang *language // nil means the default language
+}
+
+// parses the embedded packs, panicking on a bad one as parseQuotes does
+func parseLanguages() []*language {
+	res := []*language{}
+	paths, _ := fs.Glob(languageFiles, languageDirName+"/*.json")
+	for _, path := range paths {
+		data, err := languageFiles.ReadFile(path)
+		if err != nil {
+			panic("languages: " + err.Error())
+		}
+		lang, err := parseLanguage(data)
+		if err != nil {
+			panic(path + ": " + err.Error())
+		}
+		res = append(res, lang)
+	}
+	return res
+}
+
+// reads a language pack, dropping duplicate words as newWordList does
+func parseLanguage(data []byte) (*language, error) {
+	lang := &language{}
+	if err := json.Unmarshal(data, lang); err != nil {
+		return nil, err
+	}
+	lang.Words = newWordList(lang.Name, "", lang.Words).words
+	return lang, nil
+}
+
+// adds every .json pack in the languages config directory to the languages offered in settings,
+// replacing any built in pack with the same name
+func loadLanguageFiles() error {
+	dir, err := configPath(languageDirName)
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		lang, err := parseLanguage(data)
+		if err != nil || lang.Name == "" || len(lang.Words) == 0 {
+			// skip packs which aren't usable rather than failing them all
+			continue
+		}
+		registerLanguage(lang)
+	}
+	return nil
+}
+
+func registerLanguage(lang *language) {
+	languages = register(languages, lang, languageName)
+}
+
+// returns the language with the given name, or the default language if there isn't one
+func findLanguage(name string) *language {
+	if lang, ok := findNamed(languages, name, languageName); ok {
+		return lang
+	}
+	if lang, ok := findNamed(languages, defaultLanguage, languageName); ok {
+		return lang
+	}
+	return languages[0]
+}
+
+func languageNames() []string {
+	return names(languages, languageName)
+}
+
+func languageName(lang *language) string {
+	return lang.Name
+}
+
+func (l *languageWords) language() *language {
+	if l.lang == nil {
+		return findLanguage(defaultLanguage)
+	}
+	return l.lang
+}
+
+func (l *languageWords) Name() string {
+	return randomWordsSource
+}
+
+func (l *languageWords) Description() string {
+	return "Random words from the language picked in the Language setting"
+}
+
+func (l *languageWords) NextWord() string {
+	words := l.language().Words
+	return words[rand.Intn(len(words))]
+}
+
+func (l *languageWords) NextChunk(n int) []string {
+	return nextChunk(l, n)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -20,11 +22,12 @@ type tab int
 const (
 	tabTyping tab = iota
 	tabSettings
+	tabStats
 	tabHelp
 	minHeight = 17
 )
 
-var tabNames = []string{"Typing", "Settings", "Help"}
+var tabNames = []string{"Typing", "Settings", "Stats", "Help"}
 
 // bubbletea model struct - contains the sub structs for given tabs
 type model struct {
@@ -33,13 +36,17 @@ type model struct {
 	height       int
 	typingTab    *typing
 	settingsTab  *settings
+	statsTab     *stats
+	replay       *replay // a round being played back in the typing tab, nil when there isn't one
+	saveError    error   // why the last thing saved to the config directory couldn't be, nil if it was
 	centreStyle  lipgloss.Style
 	currentStyle colourTheme
 	designStyles []colourTheme
 }
 
 // initialise the initial model and its sub structs
-func initialModel() model {
+// customText is text to practise given on the command line, or empty for none
+func initialModel(customText string) model {
 	ta := textarea.New()
 	ta.SetWidth(40)
 	ta.SetHeight(8)
@@ -47,12 +54,10 @@ func initialModel() model {
 
 	m := model{
 		currentTab:  tabHelp,
-		typingTab:   &typing{gameMode: "countdown", gameCount: 30},
 		settingsTab: &settings{mode: "countdown", count: 30, time: 30},
+		statsTab:    &stats{},
 	}
 
-	m.typingTab.initTyping()
-
 	// load config
 	temp, err := m.loadConfig()
 	if err != nil {
@@ -68,14 +73,30 @@ func initialModel() model {
 
 	m.currentStyle = m.designStyles[0]
 
+	// custom word lists, languages and snippets are optional so bad ones just aren't offered
+	loadWordListFiles()
+	loadLanguageFiles()
+	loadSnippetFiles()
+	loadCorpusFiles()
+	keyHistory, _ = loadKeyStats()
+
 	m.settingsTab.initSettings(m.designStyles)
+	m.loadSettings()
+
+	if customText != "" {
+		m.settingsTab.useCustomText(customText)
+		m.currentTab = tabTyping
+	}
+
+	m.typingTab = m.settingsTab.newTyping()
+	m.statsTab.initStats()
 
 	return m
 }
 
 // Init the app and set it to full screen
 func (m model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	return tea.Batch(tea.EnterAltScreen, m.typingTab.startReveal(), m.replay.start())
 }
 
 // main update function - updates model and calls functions on key presses
@@ -89,6 +110,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.replay != nil && m.currentTab == tabTyping && msg.String() != "ctrl+c" {
+			if !m.replay.update(msg.String()) {
+				m.replay = nil
+			}
+			return m, cmd
+		}
 		switch msg.String() {
 		// non tab specific commands
 		case "ctrl+c":
@@ -109,20 +136,39 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		case "ctrl+r":
 			m = m.startRound()
-			return m, nil
+			return m, m.typingTab.startReveal()
+		case "ctrl+p":
+			if m.currentTab == tabTyping && m.typingTab.time.isFinished() {
+				m.replay = newReplay(m.typingTab.roundLog(), m.settingsTab.tape, m.settingsTab.caretColumn)
+				return m, m.replay.start()
+			}
+			return m, cmd
+		case "esc":
+			if m.currentTab == tabTyping && m.typingTab.endZen() {
+				// the next tick sees the round has ended and finishes it
+				return m, tick()
+			}
+			return m, cmd
 		case "enter":
+			if m.currentTab == tabTyping && m.typingTab.acceptsEnter() {
+				return m.typeKey("\n")
+			}
 			m = m.startRound()
 			m.currentTab = tabTyping
-			return m, nil
+			return m, m.typingTab.startReveal()
 		default:
 			switch m.currentTab {
 			case tabTyping:
-				if !m.typingTab.time.isActive() {
-					return m, tea.Batch(runTypingUpdate(m.typingTab, msg.String()), tick())
-				}
-				return m, tea.Batch(runTypingUpdate(m.typingTab, msg.String()))
+				return m.typeKey(msg.String())
 			case tabSettings:
+				previous := m.typingTab
 				m.typingTab = m.updateSettings(msg.String())
+				if m.typingTab != previous {
+					return m, m.typingTab.startReveal()
+				}
+				return m, nil
+			case tabStats:
+				m.statsTab.updateStats(msg.String())
 				return m, nil
 			}
 
@@ -133,9 +179,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.centreStyle = lipgloss.NewStyle().Width(m.width - 6).Height(m.height - 4).Align(lipgloss.Center)
 		return m, nil
 
+	case revealTickMsg:
+		// a loop for a round that has since been replaced just stops
+		if msg.round == m.typingTab && !m.typingTab.time.isFinished() {
+			m.typingTab.updateMemory()
+			return m, revealTick(msg.round)
+		}
+		return m, nil
+	case replayTickMsg:
+		// a loop for a replay that has since been closed just stops
+		if msg.r == m.replay {
+			m.replay.tick()
+			return m, replayTick(msg.r)
+		}
+		return m, nil
 	case tickMsg:
+		m.typingTab.updateMemory()
+		m.typingTab.sample()
 		if m.typingTab.roundFinished() {
-			m.typingTab.time.stopTimer(m.typingTab)
+			if result, ok, err := m.typingTab.finishRound(); ok {
+				m.saveError = errors.Join(err, m.statsTab.addResult(result, &m.typingTab.keys))
+			}
 			return m, nil
 		}
 		if m.typingTab.time.isActive() {
@@ -145,16 +209,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// passes a key press on to the typing round, starting the tick loop if the key started the round
+func (m model) typeKey(key string) (tea.Model, tea.Cmd) {
+	active := m.typingTab.time.isActive()
+	m.typingTab.typeKey(key)
+	if !active && m.typingTab.time.isActive() {
+		return m, tick()
+	}
+	return m, nil
+}
+
 // initialises new typing tab struct within model and returns it
 func (m model) startRound() model {
-	var newTypingTab *typing
-	if m.settingsTab.mode == "countdown" {
-		newTypingTab = &typing{gameMode: m.settingsTab.mode, gameCount: m.settingsTab.time}
-	} else {
-		newTypingTab = &typing{gameMode: m.settingsTab.mode, gameCount: m.settingsTab.count}
-	}
-	m.typingTab = newTypingTab
-	m.typingTab.initTyping()
+	m.typingTab = m.settingsTab.newTyping()
 	return m
 }
 
@@ -165,9 +232,13 @@ func (m model) View() string {
 	}
 	header := m.renderTabs()
 	body := renderTabContent(m)
+	if m.saveError != nil {
+		body += "\n\n" + m.currentStyle.typeTextIncorrect.Render("Couldn't save: "+m.saveError.Error())
+	}
 	rows := len(strings.Split(body, "\n"))
 	if m.height > 0 {
-		padding := (m.height-rows)/2 - 2
+		// tall content can be more than the window, in which case it just isn't padded
+		padding := max((m.height-rows)/2-2, 0)
 		body = strings.Repeat("\n", padding) + body
 	}
 	content := fmt.Sprintf("%s\n\n%s", header, body)
@@ -197,9 +268,14 @@ func (m model) renderTabs() string {
 func renderTabContent(m model) string {
 	switch m.currentTab {
 	case tabTyping:
+		if m.replay != nil {
+			return m.replay.view(m.currentStyle)
+		}
 		return m.typingTab.viewTypingTab(m.currentStyle)
 	case tabSettings:
 		return m.settingsTab.viewSettings(m.currentStyle)
+	case tabStats:
+		return m.statsTab.viewStats(m.currentStyle)
 	case tabHelp:
 		return m.displayHelp()
 	default:
@@ -212,6 +288,10 @@ func (m model) displayHelp() string {
 	res += m.currentStyle.normalText.Render("TAB and SHIFT TAB to change tabs") + "\n\n"
 	res += m.currentStyle.normalText.Render("CTRL C to quit") + "\n\n"
 	res += m.currentStyle.normalText.Render("CTRL R restart test") + "\n\n"
+	res += m.currentStyle.normalText.Render("ENTER new test (types a new line in code and zen mode)") + "\n\n"
+	res += m.currentStyle.normalText.Render("ESC finish a zen round") + "\n\n"
+	res += m.currentStyle.normalText.Render("CTRL P watch a replay of the last round") + "\n\n"
+	res += m.currentStyle.normalText.Render("CTRL W or ALT BACKSPACE delete a word") + "\n\n"
 	res += m.currentStyle.normalText.Render("← → to toggle new setting") + "\n\n"
 	res += m.currentStyle.normalText.Render("↑ ↓ change current setting")
 	return res
@@ -230,7 +310,37 @@ func main() {
 			log.Fatalf("error %v", err)
 		}
 	}()
-	p := tea.NewProgram(initialModel())
+	filePath := flag.String("file", "", "practise typing the text in this file")
+	flag.Parse()
+
+	// typing replay file.json plays back a saved round log
+	var recorded roundLog
+	replaying := flag.Arg(0) == "replay"
+	if replaying {
+		var err error
+		if recorded, err = loadRoundLog(flag.Arg(1)); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	customText, err := readCustomText(*filePath)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	var opts []tea.ProgramOption
+	if *filePath == "" && customText != "" {
+		// the text came through stdin so read keys from the terminal instead
+		opts = append(opts, tea.WithInputTTY())
+	}
+	m := initialModel(customText)
+	if replaying {
+		m.replay = newReplay(recorded, m.settingsTab.tape, m.settingsTab.caretColumn)
+		m.currentTab = tabTyping
+	}
+	p := tea.NewProgram(m, opts...)
 	if err := p.Start(); err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const (
+	markovSource       = "Markov Chain"
+	corpusDirName      = "corpora"
+	quotesCorpus       = "Quotes"
+	defaultMarkovOrder = 2
+)
+
+// every corpus selectable from the settings tab, the first one is the default
+var corpora = []*corpus{newCorpus(quotesCorpus, quotesText())}
+
+// corpus is a body of prose that Markov chain models are built from
+type corpus struct {
+	name   string
+	words  []string
+	starts []int                       // the index of every word that starts a sentence
+	models map[int]map[string][]string // built models by order, see model
+}
+
+// markovWords is a word source which strings words together the way they follow each other
+// in a corpus, so rounds read like plausible sentences rather than random words
+type markovWords struct {
+	corp    *corpus // nil means the default corpus
+	order   int     // how many previous words pick the next one
+	state   []string
+	pending []string // words of a new sentence still to be returned
+}
+
+// joins every quote into one text so they can be used as a corpus
+func quotesText() string {
+	texts := []string{}
+	for _, q := range quotes {
+		texts = append(texts, q.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// splits text into lower case words without punctuation, which the punctuation modifier
+// adds back if it's wanted, and notes where each sentence starts
+func newCorpus(name string, text string) *corpus {
+	c := &corpus{name: name, models: map[int]map[string][]string{}}
+	sentenceStart := true
+	for _, field := range strings.Fields(text) {
+		word := strings.ToLower(strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}))
+		if word == "" {
+			continue
+		}
+		if sentenceStart {
+			c.starts = append(c.starts, len(c.words))
+		}
+		c.words = append(c.words, word)
+		sentenceStart = strings.ContainsAny(field[len(field)-1:], ".!?")
+	}
+	return c
+}
+
+// returns the words which follow each run of order words in the corpus, keyed by the run
+// joined with spaces. a word appears once for every time it follows so common pairs are more likely
+func (c *corpus) model(order int) map[string][]string {
+	if m, ok := c.models[order]; ok {
+		return m
+	}
+	m := map[string][]string{}
+	for i := 0; i+order < len(c.words); i++ {
+		key := strings.Join(c.words[i:i+order], " ")
+		m[key] = append(m[key], c.words[i+order])
+	}
+	c.models[order] = m
+	return m
+}
+
+// registers every .txt file in the corpora config directory as a corpus, named after the file
+func loadCorpusFiles() error {
+	dir, err := configPath(corpusDirName)
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		c := newCorpus(name, string(data))
+		if len(c.starts) == 0 {
+			continue
+		}
+		registerCorpus(c)
+	}
+	return nil
+}
+
+func registerCorpus(c *corpus) {
+	corpora = register(corpora, c, corpusName)
+}
+
+// returns the corpus with the given name, or the default corpus if there isn't one
+func findCorpus(name string) *corpus {
+	if c, ok := findNamed(corpora, name, corpusName); ok {
+		return c
+	}
+	return corpora[0]
+}
+
+func corpusNames() []string {
+	return names(corpora, corpusName)
+}
+
+func corpusName(c *corpus) string {
+	return c.name
+}
+
+func (mw *markovWords) corpus() *corpus {
+	if mw.corp == nil {
+		return corpora[0]
+	}
+	return mw.corp
+}
+
+func (mw *markovWords) Name() string {
+	return markovSource
+}
+
+func (mw *markovWords) Description() string {
+	return "Made up sentences built from the text picked in the Corpus setting"
+}
+
+func (mw *markovWords) NextWord() string {
+	if len(mw.pending) > 0 {
+		word := mw.pending[0]
+		mw.pending = mw.pending[1:]
+		return word
+	}
+	order := max(mw.order, 1)
+	c := mw.corpus()
+	if len(mw.state) == order {
+		if next := c.model(order)[strings.Join(mw.state, " ")]; len(next) > 0 {
+			word := next[rand.Intn(len(next))]
+			mw.state = append(mw.state[1:], word)
+			return word
+		}
+	}
+	// at the start, or at a dead end where the run only appears at the end of the corpus,
+	// so carry on from the start of a random sentence
+	start := c.starts[rand.Intn(len(c.starts))]
+	end := min(start+order, len(c.words))
+	mw.state = append([]string{}, c.words[start:end]...)
+	mw.pending = append([]string{}, mw.state[1:]...)
+	return mw.state[0]
+}
+
+func (mw *markovWords) NextChunk(n int) []string {
+	return nextChunk(mw, n)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	memoryUntilTyping = -1 // memorySeconds for hiding a line as soon as the cursor reaches it
+	memoryMask        = "•"
+	revealInterval    = 100 * time.Millisecond
+)
+
+// revealTickMsg drives the hiding of lines in memory rounds, including before typing starts
+// when the normal tick loop isn't running. each round has its own loop which stops once the
+// round is finished or replaced
+type revealTickMsg struct {
+	round *typing
+}
+
+func revealTick(t *typing) tea.Cmd {
+	return tea.Tick(revealInterval, func(time.Time) tea.Msg {
+		return revealTickMsg{round: t}
+	})
+}
+
+// starts the reveal loop for a round which hides lines after a number of seconds
+func (t *typing) startReveal() tea.Cmd {
+	if t.memorySeconds <= 0 {
+		return nil
+	}
+	return revealTick(t)
+}
+
+// notes when each line on screen was first shown, so it can be hidden once its time is up
+func (t *typing) updateMemory() {
+	if t.memorySeconds == 0 {
+		return
+	}
+	if t.shownAt == nil {
+		t.shownAt = map[int]time.Time{}
+	}
+	first := t.lineOf(t.position)
+	last := min(first+t.visibleLines(), len(t.lineStarts))
+	for line := first; line < last; line++ {
+		// keyed by where the line starts in the whole round, as a countdown round drops typed lines
+		key := t.trimmedChars + t.lineStarts[line]
+		if _, ok := t.shownAt[key]; !ok {
+			t.shownAt[key] = time.Now()
+		}
+	}
+}
+
+// whether the untyped characters of a line should be hidden
+func (t typing) lineMasked(line int) bool {
+	if t.memorySeconds == 0 || t.time.isFinished() {
+		return false
+	}
+	if t.memorySeconds == memoryUntilTyping {
+		return line <= t.lineOf(t.position) && t.time.isActive()
+	}
+	shown, ok := t.shownAt[t.trimmedChars+t.lineStarts[line]]
+	return ok && time.Since(shown) >= time.Duration(t.memorySeconds)*time.Second
+}
+
+// renders a hidden character, keeping spaces and line breaks so the shape of the text is left
+func (t typing) maskChar(pos int, designStyles colourTheme) string {
+	switch char := t.content[pos]; char {
+	case " ":
+		return designStyles.typeTextDefault.Render(char)
+	case "\n":
+		return designStyles.typeTextDefault.Render("↵")
+	default:
+		return designStyles.typeTextDefault.Render(strings.Repeat(memoryMask, uniseg.StringWidth(char)))
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	classLetters     = "letters"
+	classNumbers     = "numbers"
+	classPunctuation = "punctuation"
+)
+
+// the order character classes are listed in on the result screen
+var charClasses = []string{classLetters, classPunctuation, classNumbers}
+
+// wordModifiers transforms generated words to practise more than lowercase letters.
+// it is stateful so sentences carry on correctly across chunks of words
+type wordModifiers struct {
+	punctuation bool // capitalise sentences and add punctuation marks
+	numbers     bool // swap some words for numbers
+	midSentence bool // the last word didn't end a sentence so the next isn't capitalised
+}
+
+// returns the words with the enabled modifiers applied
+func (wm *wordModifiers) apply(words []string) []string {
+	res := make([]string, 0, len(words))
+	for _, word := range words {
+		if wm.numbers && rand.Intn(10) == 0 {
+			word = strconv.Itoa(rand.Intn(10000))
+		}
+		if wm.punctuation {
+			word = wm.punctuate(word)
+		}
+		res = append(res, word)
+	}
+	return res
+}
+
+// capitalises the word if it starts a sentence then randomly wraps it or adds punctuation after it
+func (wm *wordModifiers) punctuate(word string) string {
+	if !wm.midSentence {
+		r, size := utf8.DecodeRuneInString(word)
+		word = string(unicode.ToUpper(r)) + word[size:]
+		wm.midSentence = true
+	}
+
+	switch rand.Intn(40) {
+	case 0:
+		word = "\"" + word + "\""
+	case 1:
+		word = "(" + word + ")"
+	}
+
+	switch n := rand.Intn(100); {
+	case n < 10:
+		word += ","
+	case n < 18:
+		word += "."
+		wm.midSentence = false
+	case n < 21:
+		word += "?"
+		wm.midSentence = false
+	case n < 23:
+		word += "!"
+		wm.midSentence = false
+	case n < 25:
+		word += ";"
+	case n < 27:
+		word += ":"
+	}
+	return word
+}
+
+// returns which class of character a key belongs to, or an empty string for whitespace
+func charClass(c rune) string {
+	switch {
+	case unicode.IsSpace(c):
+		return ""
+	case unicode.IsLetter(c):
+		return classLetters
+	case unicode.IsDigit(c):
+		return classNumbers
+	default:
+		return classPunctuation
+	}
+}
+
+// records a key press against the class of the character that was expected
+func (t *typing) recordClassHit(expected string, correct bool) {
+	r, _ := utf8.DecodeRuneInString(expected)
+	class := charClass(r)
+	if class == "" {
+		return
+	}
+	if t.classHits == nil {
+		t.classHits = map[string]int{}
+		t.classMisses = map[string]int{}
+	}
+	if correct {
+		t.classHits[class] += 1
+	} else {
+		t.classMisses[class] += 1
+	}
+}
+
+// returns how many key presses on non whitespace characters have been recorded
+func (t *typing) keyPresses() int {
+	total := 0
+	for _, class := range charClasses {
+		total += t.classHits[class] + t.classMisses[class]
+	}
+	return total
+}
+
+// returns how many of the recorded key presses were correct
+func (t *typing) correctKeyPresses() int {
+	hits := 0
+	for _, class := range charClasses {
+		hits += t.classHits[class]
+	}
+	return hits
+}
+
+// returns the percentage of key presses on non whitespace characters that were correct
+func (t *typing) accuracy() float64 {
+	total := t.keyPresses()
+	if total == 0 {
+		return 0
+	}
+	return float64(t.correctKeyPresses()) / float64(total) * 100
+}
+
+// renders the accuracy of key presses for each class of character that came up in the round
+func (t *typing) viewAccuracyBreakdown(designStyles colourTheme) string {
+	parts := []string{}
+	for _, class := range charClasses {
+		total := t.classHits[class] + t.classMisses[class]
+		if total == 0 {
+			continue
+		}
+		accuracy := float64(t.classHits[class]) / float64(total) * 100
+		parts = append(parts, class+" "+strconv.FormatFloat(accuracy, 'f', 1, 64)+"%")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return designStyles.normalText.Render("Accuracy: " + strings.Join(parts, "  "))
+}
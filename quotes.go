@@ -0,0 +1,72 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math/rand"
+
+	"github.com/rivo/uniseg"
+)
+
+const (
+	quoteLengthAll    = "All"
+	quoteLengthShort  = "Short"
+	quoteLengthMedium = "Medium"
+	quoteLengthLong   = "Long"
+	quoteLengthThicc  = "Thicc"
+)
+
+//go:embed quotes.json
+var quoteData []byte
+
+var (
+	quoteLengths = []string{quoteLengthAll, quoteLengthShort, quoteLengthMedium, quoteLengthLong, quoteLengthThicc}
+	quotes       = parseQuotes(quoteData)
+)
+
+type quote struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+	Author string `json:"author"`
+}
+
+// the quote corpus is compiled in so failing to parse it is a programming error
+func parseQuotes(data []byte) []quote {
+	var res []quote
+	if err := json.Unmarshal(data, &res); err != nil {
+		panic("quotes.json: " + err.Error())
+	}
+	return res
+}
+
+// returns which length group a quote falls into by its number of characters
+func (q quote) length() string {
+	switch n := uniseg.GraphemeClusterCount(q.Text); {
+	case n <= 100:
+		return quoteLengthShort
+	case n <= 300:
+		return quoteLengthMedium
+	case n <= 600:
+		return quoteLengthLong
+	default:
+		return quoteLengthThicc
+	}
+}
+
+func (q quote) attribution() string {
+	return q.Source + " - " + q.Author
+}
+
+// picks a random quote from the given length group, or from every quote for quoteLengthAll
+func randomQuote(length string) quote {
+	matching := []quote{}
+	for _, q := range quotes {
+		if length == quoteLengthAll || q.length() == length {
+			matching = append(matching, q)
+		}
+	}
+	if len(matching) == 0 {
+		matching = quotes
+	}
+	return matching[rand.Intn(len(matching))]
+}
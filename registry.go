@@ -0,0 +1,35 @@
+package main
+
+// word sources, languages and corpora are each kept in a list of named items which the user's
+// own files can add to or replace
+
+// adds an item to a list of named items, replacing any item with the same name
+func register[T any](items []T, item T, nameOf func(T) string) []T {
+	for i, existing := range items {
+		if nameOf(existing) == nameOf(item) {
+			items[i] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// returns the item with the given name, false if there isn't one
+func findNamed[T any](items []T, name string, nameOf func(T) string) (T, bool) {
+	for _, item := range items {
+		if nameOf(item) == name {
+			return item, true
+		}
+	}
+	var none T
+	return none, false
+}
+
+// returns the names of a list of items, in order
+func names[T any](items []T, nameOf func(T) string) []string {
+	res := []string{}
+	for _, item := range items {
+		res = append(res, nameOf(item))
+	}
+	return res
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	replayInterval = 50 * time.Millisecond
+	replayLeadIn   = 500 * time.Millisecond // how long before the first key the replay starts
+	replaySeek     = 5 * time.Second
+)
+
+// the speeds a replay can be played at, picked with ↑ and ↓
+var replaySpeeds = []float64{0.5, 1, 2}
+
+// replay plays a recorded round back by pressing its keys on a fresh round at the times they
+// were pressed, so it's shown exactly as the typing tab showed it
+type replay struct {
+	log         roundLog
+	round       *typing
+	next        int           // the next event to press
+	offset      time.Duration // how far into the log the replay is
+	timerStart  time.Duration // the offset the round's timer started at
+	end         time.Duration
+	speed       int // index into replaySpeeds
+	paused      bool
+	tape        bool
+	caretColumn int
+}
+
+// replayTickMsg moves a replay along. each replay has its own loop which stops once it's closed
+type replayTickMsg struct {
+	r *replay
+}
+
+func replayTick(r *replay) tea.Cmd {
+	return tea.Tick(replayInterval, func(time.Time) tea.Msg {
+		return replayTickMsg{r: r}
+	})
+}
+
+// sets up a replay of a round log, shown with the given layout
+func newReplay(log roundLog, tape bool, caretColumn int) *replay {
+	r := &replay{log: log, timerStart: log.timerStart(), speed: 1, tape: tape, caretColumn: caretColumn}
+	r.end = r.timerStart + time.Duration(log.Result.Time*float64(time.Second))
+	if len(log.Events) > 0 {
+		r.end = max(r.end, time.Duration(log.Events[len(log.Events)-1].OffsetMs)*time.Millisecond)
+	}
+	r.restart()
+	return r
+}
+
+// starts the replay's tick loop, doing nothing if there is no replay
+func (r *replay) start() tea.Cmd {
+	if r == nil {
+		return nil
+	}
+	return replayTick(r)
+}
+
+// goes back to the start of the replay with a fresh round
+func (r *replay) restart() {
+	r.rebuild()
+	if len(r.log.Events) > 0 {
+		r.offset = max(0, time.Duration(r.log.Events[0].OffsetMs)*time.Millisecond-replayLeadIn)
+	}
+	r.paused = false
+}
+
+// sets up a fresh round with the log's text and nothing typed yet
+func (r *replay) rebuild() {
+	result := r.log.Result
+	t := &typing{
+		gameMode:    result.Mode,
+		gameCount:   result.Count,
+		language:    result.Language,
+		stopOnError: r.log.StopOnError,
+		confidence:  result.Confidence,
+		tape:        r.tape,
+		caretColumn: r.caretColumn,
+		replaying:   true,
+	}
+	t.content = []string{}
+	if result.Mode != gameModeZen {
+		t.content = splitGraphemes(r.log.Text)
+	}
+	if result.Mode == gameModeCountdown {
+		t.time = &timerDown{seconds: result.Count}
+	} else {
+		t.time = &timerUp{}
+	}
+	t.prepareContent()
+	r.round = t
+	r.next = 0
+}
+
+// presses every key up to the current offset, finishing the round once the log runs out
+func (r *replay) advance() {
+	for r.next < len(r.log.Events) && r.log.Events[r.next].OffsetMs <= r.offset.Milliseconds() {
+		r.round.pressKey(r.log.Events[r.next].Key)
+		r.next++
+	}
+	if r.round.time.isActive() {
+		r.round.time.setElapsed((r.offset - r.timerStart).Seconds())
+	}
+	if r.next == len(r.log.Events) && r.offset >= r.end {
+		r.finish()
+	}
+}
+
+// stops the round where the recorded one stopped, showing the recorded result
+func (r *replay) finish() {
+	t := r.round
+	if t.time.isFinished() {
+		return
+	}
+	t.time.startTimer()
+	t.time.setElapsed(r.log.Result.Time)
+	if r.log.Result.Failed != "" {
+		t.time.fail(r.log.Result.Failed)
+	}
+	t.time.stopTimer()
+	t.result = r.log.Result
+	t.samples = r.log.Samples
+	r.paused = true
+}
+
+// moves the replay on by one tick at its speed. a paused replay still updates its round so
+// the timer, which otherwise runs on its own, stays where the replay is
+func (r *replay) tick() {
+	if !r.paused {
+		r.offset += time.Duration(float64(replayInterval) * replaySpeeds[r.speed])
+	}
+	r.advance()
+}
+
+// jumps forwards or backwards through the replay. going back replays the keys from the start
+// as there's no undoing them
+func (r *replay) seek(by time.Duration) {
+	target := min(max(r.offset+by, 0), r.end)
+	if target < r.offset {
+		r.rebuild()
+	}
+	r.offset = target
+	r.advance()
+}
+
+// handles a key press while a replay is shown, returning false once it's closed
+func (r *replay) update(key string) bool {
+	switch key {
+	case "esc":
+		return false
+	case " ":
+		if r.round.time.isFinished() {
+			r.restart()
+		} else {
+			r.paused = !r.paused
+		}
+	case "left":
+		r.seek(-replaySeek)
+	case "right":
+		r.seek(replaySeek)
+	case "up":
+		r.speed = min(r.speed+1, len(replaySpeeds)-1)
+	case "down":
+		r.speed = max(r.speed-1, 0)
+	}
+	return true
+}
+
+// renders the replayed round as the typing tab would, with where the replay is up to under it
+func (r *replay) view(designStyles colourTheme) string {
+	state := "▶"
+	if r.paused {
+		state = "⏸"
+	}
+	status := fmt.Sprintf("Replay %s %gx   %.1f / %.1f s", state, replaySpeeds[r.speed], r.offset.Seconds(), r.end.Seconds())
+	controls := "SPACE pause   ← → seek   ↑ ↓ speed   ESC close"
+	return r.round.viewTypingTab(designStyles) + "\n\n" + designStyles.normalText.Render(status) + "\n" + designStyles.normalText.Render(controls)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// short names for each game mode, used to describe the test a result is from
+var modeNames = map[string]string{
+	gameModeCountdown: "time",
+	gameModeWords:     "words",
+	gameModeQuote:     "quote",
+	gameModeCustom:    "custom",
+	gameModeCode:      "code",
+	gameModeZen:       "zen",
+	gameModeAdaptive:  "adaptive",
+}
+
+// returns a result with just what tells the round's test apart from others filled in
+func (t *typing) testResult() roundResult {
+	r := roundResult{
+		Mode:       t.gameMode,
+		Count:      t.gameCount,
+		Language:   t.language,
+		Confidence: t.confidence,
+		Modifiers:  t.modifierNames(),
+	}
+	if r.Confidence == confidenceOff {
+		// only a restricted backspace is worth noting
+		r.Confidence = ""
+	}
+	return r
+}
+
+// builds the result of a finished round from what was typed and how long it took
+func (t *typing) buildResult() roundResult {
+	r := t.testResult()
+	r.Date = time.Now()
+	r.Accuracy = t.accuracy()
+	r.Failed = t.time.failureReason()
+	r.Time = t.time.elapsed()
+	r.Ghost = t.ghostLead()
+	r.Missed = t.missed()
+	r.Correct, r.Incorrect, r.Extra = t.charCounts()
+
+	// a word is five characters, net only counts the right ones and raw counts every one typed
+	if minutes := r.Time / 60; minutes > 0 {
+		r.WPM = float64(r.Correct) / 5 / minutes
+		r.RawWPM = float64(r.Correct+r.Incorrect+r.Extra) / 5 / minutes
+	}
+	r.Consistency = consistency(t.intervals)
+	return r
+}
+
+// returns how many characters have been typed right and wrong, and how many extra keys
+// were typed past the ends of words
+func (t *typing) charCounts() (correct int, incorrect int, extra int) {
+	// characters dropped from the start of a long round were all typed
+	incorrect = t.trimmedErrors - t.trimmedMissed
+	correct = t.trimmedChars - t.trimmedErrors
+	for pos := 0; pos < t.position; pos++ {
+		switch t.characterColours[pos] {
+		case correctKey:
+			correct += 1
+		case incorrectKey:
+			incorrect += 1
+		}
+	}
+	return correct, incorrect, t.extraChars + t.extraKeys
+}
+
+// returns the modifiers a round was played with, so results can be told apart
+func (t *typing) modifierNames() []string {
+	names := []string{}
+	if t.modifiers.punctuation {
+		names = append(names, "punctuation")
+	}
+	if t.modifiers.numbers {
+		names = append(names, "numbers")
+	}
+	if t.suddenDeath {
+		names = append(names, "sudden death")
+	}
+	if t.accuracyFloor > 0 {
+		names = append(names, fmt.Sprintf("accuracy floor %d%%", t.accuracyFloor))
+	}
+	if t.stopOnError == stopOnErrorLetter || t.stopOnError == stopOnErrorWord {
+		names = append(names, "stop on "+strings.ToLower(t.stopOnError))
+	}
+	if t.blind {
+		names = append(names, "blind")
+	}
+	if t.memorySeconds != 0 {
+		names = append(names, "memory")
+	}
+	return names
+}
+
+// scores out of 100 how even the gaps between key presses were, 100 being perfectly steady.
+// it's one minus their coefficient of variation, so it doesn't depend on how fast the typing was
+func consistency(intervals []time.Duration) float64 {
+	if len(intervals) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, interval := range intervals {
+		mean += float64(interval)
+	}
+	mean /= float64(len(intervals))
+	variance := 0.0
+	for _, interval := range intervals {
+		variance += math.Pow(float64(interval)-mean, 2)
+	}
+	variance /= float64(len(intervals))
+	return max(0, 100*(1-math.Sqrt(variance)/mean))
+}
+
+// describes the test a result is from, e.g. "words 50, English 200, punctuation"
+func (r roundResult) testType() string {
+	mode := modeNames[r.Mode]
+	switch r.Mode {
+	case gameModeCountdown:
+		mode += fmt.Sprintf(" %ds", r.Count)
+	case gameModeWords, gameModeAdaptive:
+		mode += fmt.Sprintf(" %d", r.Count)
+	}
+	parts := append([]string{mode, r.Language}, r.Modifiers...)
+	if r.Confidence != "" {
+		parts = append(parts, "confidence "+strings.ToLower(r.Confidence))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renders the breakdown of a finished round
+func (r roundResult) viewResult(designStyles colourTheme) string {
+	lines := []string{
+		fmt.Sprintf("WPM %.2f   Raw %.2f   Accuracy %.1f%%   Consistency %.0f%%", r.WPM, r.RawWPM, r.Accuracy, r.Consistency),
+		fmt.Sprintf("Characters %d correct  %d incorrect  %d extra  %d missed", r.Correct, r.Incorrect, r.Extra, r.Missed),
+		"Test " + r.testType(),
+	}
+	if r.Ghost != "" {
+		lines = append(lines, "Finished "+r.Ghost)
+	}
+	return designStyles.normalText.Render(strings.Join(lines, "\n"))
+}
@@ -1,16 +1,40 @@
 package main
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
 
+const (
+	settingsPerPage = 4 // how many setting blocks fit side by side
+	optionsPerBlock = 6 // how many options are shown in a block at once
+)
+
 type settings struct {
-	mode   string
-	time   int
-	count  int
-	active int
-	sets   []*setting
+	mode          string
+	time          int
+	count         int
+	wordSource    string
+	quoteLength   string
+	customText    string // text passed in with --file or stdin, empty when there isn't any
+	codeLanguage  string
+	language      string
+	punctuation   bool
+	numbers       bool
+	suddenDeath   bool
+	accuracyFloor int // as a percentage, 0 when off
+	markovOrder   int
+	corpus        string
+	stopOnError   string
+	confidence    string
+	blind         bool
+	memorySeconds int
+	tape          bool
+	caretColumn   int
+	ghost         string
+	active        int
+	sets          []*setting
 }
 
 type setting struct {
@@ -24,13 +48,30 @@ func (s *settings) initSettings(styles []colourTheme) {
 	s.count = 30
 	s.active = 0
 	s.sets = []*setting{
-		{title: "Game Mode", position: 0, options: []string{"Time Limit", "Word Limit"}},
-		{title: "Time Limit", position: 1, options: []string{"15", "30", "60", "90", "120"}},
+		{title: "Game Mode", position: 0, options: []string{"Time Limit", "Word Limit", "Quote", "Code", "Zen", "Adaptive"}},
+		{title: "Time Limit", position: 1, options: []string{"15", "30", "60", "90", "120", "300", "600", "1800"}},
 		{title: "Word Limit", position: 1, options: []string{"15", "30", "50", "60", "100"}},
 		{title: "Theme", position: 0},
+		{title: "Word Source", position: 0},
+		{title: "Language", position: 0, options: languageNames()},
+		{title: "Quote Length", position: 0, options: quoteLengths},
+		{title: "Code Language", position: 0, options: codeLanguages()},
+		{title: "Punctuation", position: 0, options: []string{"Off", "On"}},
+		{title: "Numbers", position: 0, options: []string{"Off", "On"}},
+		{title: "Sudden Death", position: 0, options: []string{"Off", "On"}},
+		{title: "Accuracy Floor", position: 0, options: []string{"Off", "80", "90", "95", "98"}},
+		{title: "Markov Order", position: defaultMarkovOrder - 1, options: []string{"1", "2", "3"}},
+		{title: "Corpus", position: 0, options: corpusNames()},
+		{title: "Stop On Error", position: 0, options: []string{stopOnErrorOff, stopOnErrorLetter, stopOnErrorWord}},
+		{title: "Confidence", position: 0, options: []string{confidenceOff, confidenceOn, confidenceMax}},
+		{title: "Blind", position: 0, options: []string{"Off", "On"}},
+		{title: "Memory", position: 0, options: []string{"Off", "Until Typing", "1", "2", "3", "5"}},
+		{title: "Layout", position: 0, options: []string{"Lines", "Tape"}},
+		{title: "Caret Column", position: 1, options: []string{"10", "20", "30", "40", "50"}},
+		{title: "Ghost", position: 0, options: []string{ghostOff, ghostBest, "40", "60", "80", "100", "120"}},
 	}
 
-	for i, theme := range styles{
+	for i, theme := range styles {
 		if i == 5 {
 			// cannot have more than 5 styles
 			break
@@ -38,14 +79,95 @@ func (s *settings) initSettings(styles []colourTheme) {
 		s.sets[3].options = append(s.sets[3].options, theme.name)
 
 	}
+
+	s.sets[4].options = append(s.sets[4].options, names(wordSources, WordSource.Name)...)
+	s.wordSource = wordSources[0].Name()
+	s.language = defaultLanguage
+	for i, name := range s.sets[5].options {
+		if name == defaultLanguage {
+			s.sets[5].position = i
+		}
+	}
+	s.quoteLength = quoteLengthAll
+	s.codeLanguage = snippetLanguageAll
+	s.markovOrder = defaultMarkovOrder
+	s.corpus = corpora[0].name
+	s.stopOnError = stopOnErrorOff
+	s.confidence = confidenceOff
+	s.caretColumn = defaultCaretColumn
+	s.ghost = ghostOff
+}
+
+// offers a custom text game mode for the given text and selects it
+func (s *settings) useCustomText(text string) {
+	s.customText = text
+	modes := s.sets[0]
+	modes.options = append(modes.options, "Custom Text")
+	modes.position = len(modes.options) - 1
+	s.mode = gameModeCustom
+}
+
+// builds a new round from the current settings
+func (s *settings) newTyping() *typing {
+	gc := s.count
+	if s.mode == gameModeCountdown {
+		gc = s.time
+	}
+	t := &typing{
+		gameMode:      s.mode,
+		gameCount:     gc,
+		source:        s.newWordSource(),
+		quoteLength:   s.quoteLength,
+		text:          s.customText,
+		codeLanguage:  s.codeLanguage,
+		modifiers:     wordModifiers{punctuation: s.punctuation, numbers: s.numbers},
+		suddenDeath:   s.suddenDeath,
+		accuracyFloor: s.accuracyFloor,
+		stopOnError:   s.stopOnError,
+		confidence:    s.confidence,
+		blind:         s.blind,
+		memorySeconds: s.memorySeconds,
+		tape:          s.tape,
+		caretColumn:   s.caretColumn,
+	}
+	if s.mode == gameModeAdaptive {
+		// adaptive rounds always draw from the selected language, weighted by the user's weak keys
+		t.source = newAdaptiveWords(findLanguage(s.language), keyHistory)
+	}
+	t.initTyping()
+	// the ghost depends on the round's language and text so is picked once they're set
+	t.ghost = newGhost(s.ghost, t)
+	return t
+}
+
+// returns the selected word source, with the built in random words using the selected language
+// and the Markov chain using the selected corpus and order
+func (s *settings) newWordSource() WordSource {
+	source := findWordSource(s.wordSource)
+	switch source.(type) {
+	case *languageWords:
+		return &languageWords{lang: findLanguage(s.language)}
+	case *markovWords:
+		return &markovWords{corp: findCorpus(s.corpus), order: s.markovOrder}
+	}
+	return source
 }
 
 func (s *settings) viewSettings(designStyles colourTheme) string {
-	height := 6
+	height := optionsPerBlock
+	// only show the page of blocks which holds the active setting
+	pageStart := (s.active / settingsPerPage) * settingsPerPage
+	pageEnd := min(pageStart+settingsPerPage, len(s.sets))
 	fullContent := []string{}
-	for pos, set := range s.sets {
+	for pos := pageStart; pos < pageEnd; pos++ {
+		set := s.sets[pos]
 		tempContent := set.title
-		for i := 0; i < height; i++ {
+		// scroll the options so the selected one is always visible
+		first := 0
+		if set.position >= height {
+			first = set.position - height + 1
+		}
+		for i := first; i < first+height; i++ {
 			if i < len(set.options) {
 				if set.position == i {
 					tempContent += designStyles.normalText.Render("\n" + set.options[i] + " (X)")
@@ -80,10 +202,22 @@ func (s *settings) viewSettings(designStyles colourTheme) string {
 		}
 		finalString += "\n"
 	}
-	finalString += "\n\n Enter to confirm and start new round"
+	pages := (len(s.sets) + settingsPerPage - 1) / settingsPerPage
+	finalString += designStyles.normalText.Render(s.description())
+	finalString += fmt.Sprintf("\n\n Enter to confirm and start new round (page %d/%d)", pageStart/settingsPerPage+1, pages)
 	return finalString
 }
 
+// returns a line describing the active setting's current option where there is more to say than its name
+func (s *settings) description() string {
+	set := s.sets[s.active]
+	switch set.title {
+	case "Word Source":
+		return findWordSource(set.options[set.position]).Description()
+	}
+	return ""
+}
+
 func (m *model) updateSettings(key string) *typing {
 	s := m.settingsTab
 	switch key {
@@ -96,59 +230,51 @@ func (m *model) updateSettings(key string) *typing {
 		return m.typingTab
 	case "left":
 		s.active -= 1
-		if s.active == -1{
+		if s.active == -1 {
 			s.active = len(s.sets) - 1
 		}
 	case "down":
-		// get the setting tab
-		setting := s.sets[s.active]
-		setting.position += 1
-		if setting.position == len(setting.options) {
-			setting.position = 0
-		}
-
-		m.updateSettingsValues()
-		var gc int
-		if m.typingTab.gameMode == gameModeCountdown {
-			gc = m.settingsTab.time
-		} else {
-			gc = m.settingsTab.count
-		}
-
-		newTypingTab := &typing{gameMode: m.settingsTab.mode, gameCount: gc}
-		newTypingTab.initTyping()
-		return newTypingTab
+		m.moveSetting(1)
+		return m.settingsTab.newTyping()
 	case "up":
-		// get the setting tab
-		setting := s.sets[s.active]
-		setting.position -= 1
-		if setting.position == -1 {
-			setting.position = len(setting.options) - 1
-		}
-
-		m.updateSettingsValues()
-		var gc int
-		if m.typingTab.gameMode == gameModeCountdown {
-			gc = m.settingsTab.time
-		} else {
-			gc = m.settingsTab.count
-		}
-
-		newTypingTab := &typing{gameMode: m.settingsTab.mode, gameCount: gc}
-		newTypingTab.initTyping()
-		return newTypingTab
+		m.moveSetting(-1)
+		return m.settingsTab.newTyping()
 	}
 	return m.typingTab
 }
 
-func (m *model) updateSettingsValues() {
-	// get the current setting
-	set := m.settingsTab.sets[m.settingsTab.active]
+// moves the active setting's selection by delta, wrapping round, then applies and saves it
+func (m *model) moveSetting(delta int) {
+	setting := m.settingsTab.sets[m.settingsTab.active]
+	setting.position += delta
+	if setting.position == len(setting.options) {
+		setting.position = 0
+	}
+	if setting.position == -1 {
+		setting.position = len(setting.options) - 1
+	}
+
+	m.updateSettingsValues(setting)
+	m.saveError = m.settingsTab.save()
+}
+
+func (m *model) updateSettingsValues(set *setting) {
 	switch set.title {
 	case "Game Mode":
-		if m.typingTab.gameMode == gameModeCountdown {
+		switch set.options[set.position] {
+		case "Word Limit":
 			m.settingsTab.mode = gameModeWords
-		} else {
+		case "Quote":
+			m.settingsTab.mode = gameModeQuote
+		case "Custom Text":
+			m.settingsTab.mode = gameModeCustom
+		case "Code":
+			m.settingsTab.mode = gameModeCode
+		case "Zen":
+			m.settingsTab.mode = gameModeZen
+		case "Adaptive":
+			m.settingsTab.mode = gameModeAdaptive
+		default:
 			m.settingsTab.mode = gameModeCountdown
 		}
 	case "Time Limit":
@@ -157,5 +283,74 @@ func (m *model) updateSettingsValues() {
 		m.settingsTab.count, _ = strconv.Atoi(set.options[set.position])
 	case "Theme":
 		m.currentStyle = m.designStyles[set.position]
+	case "Word Source":
+		m.settingsTab.wordSource = set.options[set.position]
+	case "Quote Length":
+		m.settingsTab.quoteLength = set.options[set.position]
+	case "Language":
+		m.settingsTab.language = set.options[set.position]
+	case "Code Language":
+		m.settingsTab.codeLanguage = set.options[set.position]
+	case "Punctuation":
+		m.settingsTab.punctuation = set.options[set.position] == "On"
+	case "Numbers":
+		m.settingsTab.numbers = set.options[set.position] == "On"
+	case "Sudden Death":
+		m.settingsTab.suddenDeath = set.options[set.position] == "On"
+	case "Accuracy Floor":
+		// "Off" doesn't parse so leaves the floor at 0
+		m.settingsTab.accuracyFloor, _ = strconv.Atoi(set.options[set.position])
+	case "Markov Order":
+		m.settingsTab.markovOrder, _ = strconv.Atoi(set.options[set.position])
+	case "Corpus":
+		m.settingsTab.corpus = set.options[set.position]
+	case "Stop On Error":
+		m.settingsTab.stopOnError = set.options[set.position]
+	case "Confidence":
+		m.settingsTab.confidence = set.options[set.position]
+	case "Blind":
+		m.settingsTab.blind = set.options[set.position] == "On"
+	case "Layout":
+		m.settingsTab.tape = set.options[set.position] == "Tape"
+	case "Caret Column":
+		m.settingsTab.caretColumn, _ = strconv.Atoi(set.options[set.position])
+	case "Ghost":
+		m.settingsTab.ghost = set.options[set.position]
+	case "Memory":
+		if set.options[set.position] == "Until Typing" {
+			m.settingsTab.memorySeconds = memoryUntilTyping
+		} else {
+			// "Off" doesn't parse so leaves lines always shown
+			m.settingsTab.memorySeconds, _ = strconv.Atoi(set.options[set.position])
+		}
+	}
+}
+
+// restores the selections saved from a previous session and applies them
+func (m *model) loadSettings() {
+	saved, err := loadSettings()
+	if err != nil {
+		// no saved settings yet so keep the defaults
+		return
+	}
+	for _, set := range m.settingsTab.sets {
+		for i, option := range set.options {
+			if option == saved[set.title] {
+				set.position = i
+				m.updateSettingsValues(set)
+				break
+			}
+		}
+	}
+}
+
+// writes the current selections to the config directory
+func (s *settings) save() error {
+	saved := savedSettings{}
+	for _, set := range s.sets {
+		if len(set.options) > 0 {
+			saved[set.title] = set.options[set.position]
+		}
 	}
+	return saveSettings(saved)
 }
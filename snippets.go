@@ -0,0 +1,130 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	snippetDirName     = "snippets"
+	snippetLanguageAll = "All"
+	tabWidth           = 4 // how many spaces a tab in a snippet becomes
+)
+
+// the embedded snippets are stored as .txt so the go tool doesn't try to build them
+//
+//go:embed snippets
+var snippetFiles embed.FS
+
+var (
+	snippetLanguages = map[string]string{
+		".go": "Go",
+		".py": "Python",
+		".js": "JavaScript",
+	}
+	snippets = parseSnippets()
+)
+
+type snippet struct {
+	name     string
+	language string
+	code     string
+}
+
+func parseSnippets() []snippet {
+	res := []snippet{}
+	paths, _ := fs.Glob(snippetFiles, snippetDirName+"/*")
+	for _, p := range paths {
+		data, err := snippetFiles.ReadFile(p)
+		if err != nil {
+			panic("snippets: " + err.Error())
+		}
+		res = append(res, newSnippet(path.Base(p), string(data)))
+	}
+	return res
+}
+
+// builds a snippet, working out its language from the file extension
+func newSnippet(filename string, code string) snippet {
+	name := strings.TrimSuffix(filename, ".txt")
+	ext := filepath.Ext(name)
+	language, ok := snippetLanguages[ext]
+	if !ok {
+		language = strings.ToUpper(strings.TrimPrefix(ext, "."))
+	}
+	if language == "" {
+		language = "Other"
+	}
+	return snippet{name: name, language: language, code: normaliseCode(code)}
+}
+
+// adds every file in the snippets config directory to the snippets which can be typed
+func loadSnippetFiles() error {
+	dir, err := configPath(snippetDirName)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if s := newSnippet(entry.Name(), string(data)); s.code != "" {
+			snippets = append(snippets, s)
+		}
+	}
+	return nil
+}
+
+// returns the languages there are snippets for, sorted, after snippetLanguageAll
+func codeLanguages() []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, s := range snippets {
+		if !seen[s.language] {
+			seen[s.language] = true
+			res = append(res, s.language)
+		}
+	}
+	sort.Strings(res)
+	return append([]string{snippetLanguageAll}, res...)
+}
+
+// picks a random snippet in the given language, or in any language for snippetLanguageAll
+func randomSnippet(language string) snippet {
+	matching := []snippet{}
+	for _, s := range snippets {
+		if language == snippetLanguageAll || s.language == language {
+			matching = append(matching, s)
+		}
+	}
+	if len(matching) == 0 {
+		matching = snippets
+	}
+	return matching[rand.Intn(len(matching))]
+}
+
+// tidies code so every character can be typed - tabs become spaces, line endings
+// become \n and trailing whitespace and blank lines at either end are dropped
+func normaliseCode(code string) string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	code = strings.ReplaceAll(code, "\t", strings.Repeat(" ", tabWidth))
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	recentResults    = 8 // how many of the latest results are listed
+	weakKeysShown    = 8 // how many of the user's weakest keys are listed
+	statsLanguageAll = "All"
+)
+
+type stats struct {
+	results   []roundResult
+	filter    int       // index into filters() of the language results are shown for
+	lastRound *keyStats // the key stats of the last round played, nil before there's been one
+	heatmap   int       // index into heatmapViews of the heatmap shown
+}
+
+func (s *stats) initStats() {
+	// with no readable history the tab just starts empty
+	s.results, _ = loadHistory()
+	if path, err := configPath(lastRoundFilename); err == nil {
+		if log, err := loadRoundLog(path); err == nil {
+			s.lastRound = log.keyStats()
+		}
+	}
+}
+
+// keeps a finished round's result and saves it to the history file, and keeps its key stats
+// for the last round heatmaps
+func (s *stats) addResult(r roundResult, keys *keyStats) error {
+	s.results = append(s.results, r)
+	s.lastRound = keys
+	return appendHistory(r)
+}
+
+// returns the languages results can be filtered by, after statsLanguageAll
+func (s *stats) filters() []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, r := range s.results {
+		if !seen[r.Language] {
+			seen[r.Language] = true
+			res = append(res, r.Language)
+		}
+	}
+	sort.Strings(res)
+	return append([]string{statsLanguageAll}, res...)
+}
+
+func (s *stats) filtered() []roundResult {
+	language := s.filters()[s.filter]
+	if language == statsLanguageAll {
+		return s.results
+	}
+	return filterByLanguage(s.results, language)
+}
+
+func (s *stats) updateStats(key string) {
+	filters := s.filters()
+	switch key {
+	case "right":
+		s.filter += 1
+		if s.filter >= len(filters) {
+			s.filter = 0
+		}
+	case "left":
+		s.filter -= 1
+		if s.filter < 0 {
+			s.filter = len(filters) - 1
+		}
+	case "down":
+		s.heatmap = (s.heatmap + 1) % len(heatmapViews)
+	case "up":
+		s.heatmap = (s.heatmap + len(heatmapViews) - 1) % len(heatmapViews)
+	}
+}
+
+func (s *stats) viewStats(designStyles colourTheme) string {
+	if s.filter >= len(s.filters()) {
+		s.filter = 0
+	}
+	results := s.filtered()
+	res := designStyles.tabTextActive.Render("Language: "+s.filters()[s.filter]) + "\n\n"
+	if len(results) == 0 {
+		return res + designStyles.normalText.Render("No rounds finished yet") + "\n\n← → to change language"
+	}
+
+	best, total, accuracy := 0.0, 0.0, 0.0
+	for _, r := range results {
+		if r.Failed == "" {
+			// a failed round was cut short so doesn't count as a best
+			best = max(best, r.WPM)
+		}
+		total += r.WPM
+		accuracy += r.Accuracy
+	}
+	n := float64(len(results))
+	res += designStyles.normalText.Render(fmt.Sprintf("Rounds %d   Best %.2f WPM   Average %.2f WPM   Accuracy %.1f%%", len(results), best, total/n, accuracy/n)) + "\n\n"
+	if weak := keyHistory.weakestKeys(weakKeysShown); len(weak) > 0 {
+		for i, key := range weak {
+			weak[i] = keyLabel(key)
+		}
+		res += designStyles.normalText.Render("Weakest keys: "+strings.Join(weak, " ")) + "\n\n"
+	}
+	res += s.viewHeatmap(designStyles)
+
+	// newest first
+	for i := len(results) - 1; i >= 0 && i >= len(results)-recentResults; i-- {
+		r := results[i]
+		line := fmt.Sprintf("%s  %-10s %-4s %-14s %7.2f WPM %6.1f%%", r.Date.Local().Format("2006-01-02 15:04"), r.Mode, countLabel(r.Count), r.Language, r.WPM, r.Accuracy)
+		if r.Failed != "" {
+			line += "  failed"
+		}
+		if r.Confidence != "" {
+			line += "  confidence " + strings.ToLower(r.Confidence)
+		}
+		res += designStyles.normalText.Render(line) + "\n"
+	}
+	return res + "\n← → to change language   ↑ ↓ to change heatmap"
+}
+
+// renders the selected heatmap of the user's keys
+func (s *stats) viewHeatmap(designStyles colourTheme) string {
+	view := heatmapViews[s.heatmap]
+	keys := keyHistory
+	if view.lastRound {
+		keys = s.lastRound
+	}
+	res := designStyles.tabTextActive.Render(view.title) + "\n\n"
+	if keys == nil || len(keys.Chars) == 0 {
+		return res + designStyles.normalText.Render("No keys typed yet") + "\n\n"
+	}
+	return res + viewKeyboard(heatScores(keys.byKey(), view.slowness), designStyles) + "\n\n"
+}
+
+// names the whitespace keys, which don't show up on their own
+func keyLabel(key string) string {
+	switch key {
+	case " ":
+		return "space"
+	case "\n":
+		return "enter"
+	}
+	return key
+}
+
+func countLabel(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprint(count)
+}
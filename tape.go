@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+const (
+	tapeWidth          = maxLineWidth // how many cells wide the tape is
+	defaultCaretColumn = 20
+)
+
+// renders the content as a single line which scrolls past a caret fixed at caretColumn,
+// with what has been typed to its left and what's still to type to its right
+func (t typing) viewTape(designStyles colourTheme) string {
+	// go back as far as still fits to the left of the caret
+	start, width := t.position, 0
+	for start > 0 && width+t.cellWidth(start-1) <= t.caretColumn {
+		start -= 1
+		width += t.cellWidth(start)
+	}
+	// pad the start of the round so the caret doesn't move
+	output := strings.Repeat(" ", t.caretColumn-width)
+	width = t.caretColumn
+	for pos := start; pos < len(t.content); pos++ {
+		if pos >= t.position {
+			if width+t.cellWidth(pos) > tapeWidth {
+				break
+			}
+			width += t.cellWidth(pos)
+		}
+		output += t.viewChar(pos, t.lineMasked(t.lineOf(pos)), designStyles)
+	}
+	return output
+}
+
+// returns how many cells the character at a position takes up on the tape
+func (t typing) cellWidth(pos int) int {
+	if t.content[pos] == "\n" {
+		// shown as a single ↵
+		return 1
+	}
+	return uniseg.StringWidth(t.content[pos])
+}
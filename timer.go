@@ -15,30 +15,37 @@ type tickMsg time.Time
 // struct for a timer which goes up - used in 'words' gamemode where player is timed
 // how long it takes to complete a set of words
 type timerUp struct {
-	start      time.Time
-	started    bool
-	finished   bool
-	finishTime float64
-	wpm        float64
+	start       time.Time
+	started     bool
+	finished    bool
+	finishTime  float64
+	attribution string // shown under the result when the content came from somewhere, e.g. a quote
+	failReason  string // why the round failed, empty unless it did
 }
 
 // struct for a countdown timer - used in 'countdown' gamemode
 // this also includes a countdown bar as well
 type timerDown struct {
-	seconds  int
-	start    time.Time
-	started  bool
-	finished bool
-	wpm      float64
+	seconds    int
+	start      time.Time
+	started    bool
+	finished   bool
+	finishTime float64
+	failReason string // why the round failed, empty unless it did
 }
 
 // interface for both types of timer
 type timer interface {
 	displayTimer(designStyles colourTheme) string
 	startTimer()
-	stopTimer(ty *typing)
+	stopTimer()
 	isFinished() bool
 	isActive() bool
+	elapsed() float64
+	setElapsed(seconds float64)
+	fail(reason string)
+	isFailed() bool
+	failureReason() string
 }
 
 func (t *timerUp) isActive() bool {
@@ -56,11 +63,39 @@ func (t *timerDown) isActive() bool {
 }
 
 
+// marks the round as failed, it is then finished on the next tick like any other round
+func (t *timerUp) fail(reason string) {
+	if !t.finished {
+		t.failReason = reason
+	}
+}
+
+func (t *timerDown) fail(reason string) {
+	if !t.finished {
+		t.failReason = reason
+	}
+}
+
+func (t *timerUp) isFailed() bool {
+	return t.failReason != ""
+}
+
+func (t *timerDown) isFailed() bool {
+	return t.failReason != ""
+}
+
+func (t *timerUp) failureReason() string {
+	return t.failReason
+}
+
+func (t *timerDown) failureReason() string {
+	return t.failReason
+}
+
 func (t *timerUp) startTimer() {
 	if !t.started {
 		t.started = true
 		t.start = time.Now()
-		t.wpm = -1
 	}
 }
 
@@ -68,43 +103,52 @@ func (t *timerDown) startTimer() {
 	if !t.started {
 		t.started = true
 		t.start = time.Now()
-		t.wpm = -1
 	}
 }
 
-// calculates words per minute with formula
-// wpm = (characters typed - incorrect characters) / 5 * 60/time taken
-func calcWPM(ty *typing, time float64) float64 {
-	errorCount := 0
-	var i int
-	for i = 0; i < len(ty.content); i++ {
-		if ty.characterColours[i] == defaultKey {
-			break
-		}
-		if ty.characterColours[i] == incorrectKey {
-			errorCount += 1
-		}
-	}
-	return (float64(i) - float64(errorCount)) / 5 * (float64(60) / time)
-}
-
-func (t *timerUp) stopTimer(ty *typing) {
+func (t *timerUp) stopTimer() {
 	if !t.finished {
 		t.finished = true
 		t.finishTime = time.Since(t.start).Seconds()
-		// calculate words per minute
-		// iterate over content for every space we add 1 to word count, also make an error count as we go through
-		t.wpm = calcWPM(ty, t.finishTime)
 	}
 }
 
-func (t *timerDown) stopTimer(ty *typing) {
+func (t *timerDown) stopTimer() {
 	if !t.finished {
 		t.finished = true
-		t.wpm = calcWPM(ty, float64(t.seconds))
+		t.finishTime = float64(t.seconds)
+		if t.failReason != "" {
+			// a failed round stops early so only the time actually typed for counts
+			t.finishTime = min(t.finishTime, time.Since(t.start).Seconds())
+		}
 	}
 }
 
+// moves the start of a running timer so it shows the given time, used by replays which run
+// at their own speed
+func (t *timerUp) setElapsed(seconds float64) {
+	t.start = time.Now().Add(-time.Duration(seconds * float64(time.Second)))
+}
+
+func (t *timerDown) setElapsed(seconds float64) {
+	t.start = time.Now().Add(-time.Duration(seconds * float64(time.Second)))
+}
+
+// returns how many seconds the round has been typed for so far, or was typed for once it's finished
+func (t *timerUp) elapsed() float64 {
+	if !t.finished && t.started {
+		return time.Since(t.start).Seconds()
+	}
+	return t.finishTime
+}
+
+func (t *timerDown) elapsed() float64 {
+	if !t.finished && t.started {
+		return min(float64(t.seconds), time.Since(t.start).Seconds())
+	}
+	return t.finishTime
+}
+
 func (t *timerUp) isFinished() bool {
 	return t.finished
 }
@@ -117,7 +161,14 @@ func (t *timerUp) displayTimer(designStyles colourTheme) string {
 	if t.started && !t.finished {
 		return designStyles.normalText.Render(fmt.Sprintf("%.2f s", time.Since(t.start).Seconds()))
 	} else if t.finished {
-		return designStyles.normalText.Render(fmt.Sprintf("%.2f s\nWPM = %.2f", t.finishTime, t.wpm))
+		res := designStyles.normalText.Render(fmt.Sprintf("%.2f s", t.finishTime))
+		if t.attribution != "" {
+			res += "\n\n" + designStyles.normalText.Render(t.attribution)
+		}
+		if t.failReason != "" {
+			res += "\n\n" + designStyles.typeTextIncorrect.Render("FAILED - "+t.failReason)
+		}
+		return res
 	}
 	return "0s"
 }
@@ -128,7 +179,13 @@ func (t *timerDown) displayTimer(designStyles colourTheme) string {
 	if t.started && !t.finished {
 		return t.displayBar((float64(t.seconds)-time.Since(t.start).Seconds())/float64(t.seconds)*100, designStyles) + designStyles.normalText.Render(fmt.Sprintf(" %.2f s", float64(t.seconds)-time.Since(t.start).Seconds()))
 	} else if t.finished {
-		return t.displayBar(0, designStyles) + designStyles.normalText.Render(fmt.Sprintf(" %.2f s\nWPM = %.2f", 0.0, t.wpm))
+		// a failed round stops early so show the time it had left
+		left := float64(t.seconds) - t.finishTime
+		res := t.displayBar(left/float64(t.seconds)*100, designStyles) + designStyles.normalText.Render(fmt.Sprintf(" %.2f s", left))
+		if t.failReason != "" {
+			res += "\n\n" + designStyles.typeTextIncorrect.Render("FAILED - "+t.failReason)
+		}
+		return res
 	}
 	return t.displayBar(100, designStyles) + designStyles.normalText.Render(fmt.Sprintf(" %v.00 s", t.seconds))
 }
@@ -1,186 +1,738 @@
 package main
 
 import (
-	"math/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
 )
 
 const (
 	defaultKey        = "default"
 	correctKey        = "correct"
 	incorrectKey      = "incorrect"
+	missedKey         = "missed" // skipped over by pressing space part way through a word
 	gameModeCountdown = "countdown"
 	gameModeWords     = "words"
+	gameModeQuote     = "quote"
+	gameModeCustom    = "custom"
+	gameModeCode      = "code"
+	gameModeZen       = "zen"
+	gameModeAdaptive  = "adaptive"
+	quotesLanguage    = "English" // every quote in the corpus is in English
+	customLanguage    = "Custom Text"
+	zenLanguage       = "None"
+	maxLineWidth      = 70  // how many characters wide the text can be before it wraps
+	visibleLines      = 3   // how many lines of text are shown at once
+	codeVisibleLines  = 10  // code needs more context so shows more lines
+	blindReviewLines  = 10  // how many typed lines a blind round shows once it's over
+	accuracyGrace     = 20  // key presses before the accuracy floor is enforced, so one early slip can't fail a round
+	chunkWords        = 50  // how many words a countdown round generates at a time
+	refillMargin      = 150 // characters left ahead of the cursor when the next chunk is generated
+)
+
+// how the cursor behaves after a wrong key
+const (
+	stopOnErrorOff    = "Off"    // the wrong key is marked and the cursor moves on
+	stopOnErrorLetter = "Letter" // the cursor waits until the right key is pressed
+	stopOnErrorWord   = "Word"   // a word can't be finished with space until it's right
+)
+
+// how far back backspace can go
+const (
+	confidenceOff = "Off" // anywhere
+	confidenceOn  = "On"  // only within the current word
+	confidenceMax = "Max" // not at all
 )
 
 var (
-	red         = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	green       = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
-	blue        = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
-	commonWords = []string{
-		"the", "be", "and", "of", "a",
-		"in", "to", "have", "it", "that",
-		"for", "you", "he", "with", "on",
-		"do", "at", "by", "not", "this",
-		"but", "from", "or", "which", "one",
-		"would", "all", "there", "say", "who",
-		"like", "when", "make", "them", "know",
-		"if", "time", "no", "take", "people",
-		"out", "into", "just", "see", "him",
-		"your", "come", "could", "now", "than",
-		"other", "how", "then", "its", "our",
-		"two", "more", "these", "no", "way",
-		"well", "only", "my", "other", "could",
-		"some", "than", "first", "last", "over",
-		"such", "my", "made", "make", "after",
-		"may", "much", "where", "need", "should",
-		"why", "long", "know", "using", "good",
-		"think", "see", "look", "want", "better",
-		"here", "need", "much", "want", "more",
-		"he's", "she's", "they're", "we're", "you're",
-		"I", "we", "you", "he", "she",
-	}
+	red   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	green = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	blue  = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
 )
 
 type typing struct {
-	content          string
+	content          []string // the text to type split into grapheme clusters, so each element is one character as the user sees it
 	position         int
 	characterColours []string
 	extraKeys        int    // this is how many extra key presses the user did after the end of a word - it resets every time they press space after finishing a word
+	extraColour      string // the colour of the last character of the word before any extra keys, put back once they're removed
+	extraChars       int    // extra key presses left in words already finished with space
 	gameMode         string // either words or countdown
 	gameCount        int    // this is either how many words to complete or how long you have to type as many words as possible depending on game
 	time             timer
+	source           WordSource // where generated words come from
+	quoteLength      string     // which length group quotes are picked from in quote mode
+	quote            quote      // the quote being typed in quote mode
+	text             string     // the user's own text in custom mode
+	codeLanguage     string     // which language snippets are picked from in code mode
+	modifiers        wordModifiers
+	language         string         // the language the content is in, recorded with the result
+	classHits        map[string]int // correct key presses for each class of character
+	classMisses      map[string]int // incorrect key presses for each class of character
+	lineStarts       []int          // the position each wrapped line of content starts at
+	ended            bool           // the user asked to finish a zen round
+	suddenDeath      bool           // fail the round on the first incorrect key press
+	accuracyFloor    int            // fail the round if accuracy drops below this percentage, 0 for no floor
+	trimmedChars     int            // typed characters dropped from the start of the content in a countdown round
+	trimmedErrors    int            // how many of the dropped characters were incorrect or missed
+	trimmedMissed    int            // how many of the dropped characters were missed
+	keys             keyStats       // every key press in the round, added to keyHistory when it finishes
+	lastKey          time.Time      // when the previous key was pressed, to time each key press
+	stopOnError      string         // how the cursor behaves after a wrong key, one of the stopOnError consts
+	confidence       string         // how far back backspace can go, one of the confidence consts
+	blind            bool           // hide whether keys were right until the round is over
+	tape             bool           // show the content as one scrolling line instead of wrapped lines
+	caretColumn      int            // the column the caret stays at in the tape layout
+
+	// memory rounds hide lines after they've been shown for a while
+	memorySeconds int               // how long lines are shown before being hidden, 0 never hides them, see memoryUntilTyping
+	shownAt       map[int]time.Time // when each line was first on screen, by where it starts in the round
+
+	intervals []time.Duration // the time between each key press and the one before, for consistency
+	result    roundResult     // the round's result once it's finished
+
+	samples       []wpmSample // how the round went each second, for the chart
+	sampledTyped  int         // characters typed as of the last sample
+	sampledErrors int         // mistakes made as of the last sample
+
+	created   time.Time  // when the round was set up, key events are timed from this
+	events    []keyEvent // every key press in the round
+	generated []string   // all the text generated for the round, which a countdown round drops from content as it goes
+	replaying bool       // the round is a replay so its content is a recorded round's, and none is generated
+
+	ghost *ghost // races the user through the round, nil for none
+
+	extraRuns map[int]extraRun // extra keys left in finished words, by where the space after the word is in the round
 }
 
-func runTypingUpdate(t *typing, char string) tea.Cmd {
-	return func() tea.Msg {
-		if !t.time.isFinished() {
-			t.updateTypingTab(char)
-		}
-		return nil
+// extraRun is the extra keys typed after the end of a word, kept when the word is finished
+// with space so they can be put back if the space is deleted
+type extraRun struct {
+	keys   int
+	colour string // the colour the word's last character had before the extra keys
+}
+
+// applies a key press to the round. it has to run in Update rather than a Cmd as it changes the
+// content, which View reads at the same time
+func (t *typing) typeKey(char string) {
+	if !t.time.isFinished() && !t.time.isFailed() {
+		t.pressKey(char)
+		t.checkFailure()
+		t.extendContent()
 	}
 }
 
 func (t *typing) initTyping() {
-	// options are words (how long to do n words) or countdown (how many words in n time)
+	// options are words (how long to do n words), countdown (how many words in n time) or quote (how long to type a quote)
+	if t.source == nil {
+		t.source = wordSources[0]
+	}
+	switch source := t.source.(type) {
+	case *languageWords:
+		t.language = source.language().Name
+	case *adaptiveWords:
+		t.language = source.lang.Name
+	default:
+		t.language = t.source.Name()
+	}
 	switch t.gameMode {
-	case gameModeWords:
-		rand.Seed(time.Now().UnixNano())
-		words := ""
-		for i := 1; i < t.gameCount+1; i++ {
-			words += commonWords[rand.Intn(len(commonWords))]
-			if i%15 == 0 {
-				words += "\n"
-			} else {
-				words += " "
-			}
-
-		}
-		t.content = words
+	case gameModeWords, gameModeAdaptive:
+		t.content = splitGraphemes(t.generateWords(t.gameCount))
 
 		t.time = &timerUp{started: false, finished: false}
 	case gameModeCountdown:
-		rand.Seed(time.Now().UnixNano())
-		words := ""
-		for i := 0; i < 300; i++ {
-			words += commonWords[rand.Intn(len(commonWords))]
-			words += " "
-		}
-		t.content = words
-		// generate content
+		// start with a couple of chunks, more are generated as the cursor nears the end
+		t.content = splitGraphemes(t.generateWords(2 * chunkWords))
 		t.time = &timerDown{started: false, finished: false, seconds: t.gameCount}
+	case gameModeQuote:
+		t.quote = randomQuote(t.quoteLength)
+		// the trailing space is the end of round marker like the other modes
+		t.content = splitGraphemes(t.quote.Text + " ")
+		t.language = quotesLanguage
+		t.time = &timerUp{started: false, finished: false, attribution: t.quote.attribution()}
+	case gameModeCustom:
+		t.content = splitGraphemes(t.text + " ")
+		t.language = customLanguage
+		t.time = &timerUp{started: false, finished: false}
+	case gameModeCode:
+		snip := randomSnippet(t.codeLanguage)
+		t.content = splitGraphemes(snip.code + "\n")
+		t.language = snip.language
+		t.time = &timerUp{started: false, finished: false, attribution: snip.name}
+	case gameModeZen:
+		// there's nothing to copy, whatever the user types becomes the content
+		t.content = []string{}
+		t.language = zenLanguage
+		t.time = &timerUp{started: false, finished: false}
 	}
+	t.prepareContent()
+}
+
+// sets up the round to type its content, once the content and timer have been picked
+func (t *typing) prepareContent() {
+	t.wrapContent()
+	t.created = time.Now()
+	t.generated = append(t.generated, strings.Join(t.content, ""))
 
 	for i := 0; i < len(t.content); i++ {
 		t.characterColours = append(t.characterColours, defaultKey)
 	}
+	t.skipIndentation()
+	t.updateMemory()
+}
+
+// splits text into grapheme clusters - the characters a user sees and types, which
+// can be made of several runes (e.g. an accent combined with a letter)
+func splitGraphemes(text string) []string {
+	res := []string{}
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		res = append(res, g.Str())
+	}
+	return res
+}
+
+// returns n words from the word source with any modifiers applied, each followed by a space
+func (t *typing) generateWords(n int) string {
+	return strings.Join(t.modifiers.apply(t.source.NextChunk(n)), " ") + " "
+}
+
+// generates another chunk of words when the cursor gets near the end of a countdown round,
+// dropping lines typed long ago so however long the round is the content stays the same size
+func (t *typing) extendContent() {
+	if t.gameMode != gameModeCountdown || t.replaying || len(t.content)-t.position > refillMargin {
+		return
+	}
+	t.trimTyped()
+	chunk := t.generateWords(chunkWords)
+	t.generated = append(t.generated, chunk)
+	words := splitGraphemes(chunk)
+	t.content = append(t.content, words...)
+	for range words {
+		t.characterColours = append(t.characterColours, defaultKey)
+	}
+	t.wrapContent()
+}
+
+// drops every line before the one above the cursor, keeping count of what was typed on them
+func (t *typing) trimTyped() {
+	line := t.lineOf(t.position)
+	if line < 2 {
+		return
+	}
+	// the line above is kept so backspace can still reach it
+	cut := t.lineStarts[line-1]
+	for _, colour := range t.characterColours[:cut] {
+		switch colour {
+		case incorrectKey:
+			t.trimmedErrors += 1
+		case missedKey:
+			t.trimmedErrors += 1
+			t.trimmedMissed += 1
+		}
+	}
+	t.trimmedChars += cut
+	// copy what's left so the dropped characters can be freed
+	t.content = append([]string{}, t.content[cut:]...)
+	t.characterColours = append([]string{}, t.characterColours[cut:]...)
+	t.position -= cut
+}
+
+// whether enter should be typed as a newline rather than starting a new round
+func (t *typing) acceptsEnter() bool {
+	return (t.gameMode == gameModeCode || t.gameMode == gameModeZen) && !t.time.isFinished()
+}
+
+// asks a running zen round to finish, returning false if there isn't one
+func (t *typing) endZen() bool {
+	if t.gameMode != gameModeZen || !t.time.isActive() {
+		return false
+	}
+	t.ended = true
+	return true
+}
+
+// handles a key in zen mode, where whatever is typed is appended to the content
+func (t *typing) updateZen(key string) {
+	switch key {
+	case "backspace":
+		if len(t.content) > 0 {
+			t.content = t.content[:len(t.content)-1]
+			t.characterColours = t.characterColours[:len(t.characterColours)-1]
+		}
+	default:
+		// only keys which produce a single character are text, which rules out keys like "up"
+		if uniseg.GraphemeClusterCount(key) != 1 {
+			return
+		}
+		t.content = append(t.content, key)
+		t.characterColours = append(t.characterColours, correctKey)
+		t.recordClassHit(key, true)
+		t.keyInterval()
+		t.time.startTimer()
+	}
+	t.position = len(t.content)
+	t.wrapContent()
+}
+
+// moves the cursor past indentation at the start of a line, as an editor would auto indent
+func (t *typing) skipIndentation() {
+	if t.position > 0 && t.content[t.position-1] != "\n" {
+		return
+	}
+	for t.position < len(t.content)-1 && t.content[t.position] == " " {
+		t.characterColours[t.position] = correctKey
+		t.position += 1
+	}
+}
+
+// returns how many auto indented spaces are directly before a position,
+// which is none unless they are the indentation of a line after a newline
+func (t *typing) indentationBefore(position int) int {
+	start := position
+	for start > 0 && t.content[start-1] == " " {
+		start -= 1
+	}
+	if start > 0 && t.content[start-1] == "\n" {
+		return position - start
+	}
+	return 0
 }
 
 func (t *typing) updateTypingTab(key string) {
+	if t.gameMode == gameModeZen {
+		t.updateZen(key)
+		return
+	}
 	switch key {
 	case "backspace":
-		if t.position > 0 {
+		if t.position > 0 && t.canBackspace() {
+			if t.position < len(t.content) && t.characterColours[t.position] == incorrectKey {
+				// stop on letter leaves a wrong key marked on the character under the cursor
+				t.characterColours[t.position] = defaultKey
+			}
 			if t.extraKeys == 1 {
 				// In this case the user finally removed all extra chars
-				t.extraKeys = 0
-				t.characterColours[t.position] = correctKey
-				t.characterColours[t.position-1] = correctKey
+				t.removeExtraKeys()
 			} else if t.extraKeys > 1 {
 				t.extraKeys -= 1
 				// mkae sure the previosu character is red
-				t.characterColours[t.position] = incorrectKey
+				t.characterColours[t.position-1] = incorrectKey
 			} else {
+				// undo auto indentation along with the line break before it
+				indent := t.indentationBefore(t.position)
+				for i := t.position - indent; i < t.position; i++ {
+					t.characterColours[i] = defaultKey
+				}
+				t.position -= indent
 				t.characterColours[t.position-1] = defaultKey
 				t.position -= 1
+				// backspacing over the space after a word with extra keys brings them back
+				t.restoreExtraKeys()
+				// backspacing over the space after a skipped word goes back to where it was skipped from
+				for t.position > 0 && t.characterColours[t.position-1] == missedKey {
+					t.characterColours[t.position-1] = defaultKey
+					t.position -= 1
+				}
 			}
 		}
+	case "ctrl+h", "alt+backspace", "ctrl+w":
+		// ctrl+backspace is sent as ctrl+h by most terminals
+		if t.confidence != confidenceMax || t.mustFixWord() {
+			t.deleteWord()
+		}
 	default:
+		// as in zen mode, keys like "up" or "ctrl+a" aren't typed characters
+		if uniseg.GraphemeClusterCount(key) != 1 {
+			return
+		}
 		if t.position < len(t.content) {
 			switch t.content[t.position] {
-			case ' ':
-				if key == " " {
+			case " ", "\n":
+				if key == t.content[t.position] {
+					if t.stopOnError == stopOnErrorWord && t.wordHasErrors() {
+						// the word has to be fixed before moving on to the next
+						return
+					}
 					t.characterColours[t.position] = correctKey
+					t.recordKey(true)
+					t.keepExtraKeys()
 					t.position += 1
-					t.extraKeys = 0
-				} else {
+					t.skipIndentation()
+				} else if t.stopOnError == stopOnErrorLetter {
+					// the cursor waits for the space rather than taking extra characters,
+					// but the wrong key still counts against accuracy
+					t.recordClassHit(key, false)
+					t.recordKey(false)
+				} else if t.position > 0 {
 					// incorrect characters after word
+					if t.extraKeys == 0 {
+						t.extraColour = t.characterColours[t.position-1]
+					}
 					t.extraKeys += 1
 					t.characterColours[t.position-1] = incorrectKey
+					t.recordClassHit(key, false)
 				}
 			default:
-				if key == string(t.content[t.position]) {
+				if key == " " && t.stopOnError == stopOnErrorOff && t.skipWord() {
+					return
+				}
+				correct := key == t.content[t.position]
+				if correct {
 					t.characterColours[t.position] = correctKey
 				} else {
 					t.characterColours[t.position] = incorrectKey
 				}
-				t.position += 1
+				t.recordClassHit(t.content[t.position], correct)
+				t.recordKey(correct)
 				// make timer set to started as user must have pressed a key now
 				t.time.startTimer()
+				if !correct && t.stopOnError == stopOnErrorLetter {
+					// stay on the character, it shows as wrong until the right key is pressed
+					return
+				}
+				t.position += 1
 			}
 		}
 	}
 }
 
+// moves the cursor to the start of the next word, marking the rest of the current word as missed.
+// returns false if the cursor is at the start of a word, where there's nothing to skip
+func (t *typing) skipWord() bool {
+	if t.position == 0 || t.content[t.position-1] == " " || t.content[t.position-1] == "\n" {
+		return false
+	}
+	for t.position < len(t.content)-1 && t.content[t.position] != " " && t.content[t.position] != "\n" {
+		t.characterColours[t.position] = missedKey
+		// a skipped character counts against accuracy like a wrong key
+		t.recordClassHit(t.content[t.position], false)
+		t.position += 1
+	}
+	if t.position < len(t.content)-1 {
+		// step over the space too, unless it's the one ending the round
+		t.characterColours[t.position] = correctKey
+		t.position += 1
+		t.extraKeys = 0
+		t.skipIndentation()
+	}
+	return true
+}
+
+// returns how many characters have been skipped over
+func (t *typing) missed() int {
+	missed := t.trimmedMissed
+	for _, colour := range t.characterColours {
+		if colour == missedKey {
+			missed += 1
+		}
+	}
+	return missed
+}
+
+// whether any character of the word before the cursor was typed wrong
+func (t *typing) wordHasErrors() bool {
+	return t.extraKeys > 0 || t.errorsBefore(t.position)
+}
+
+// whether any character of the word ending just before a position was typed wrong or missed
+func (t *typing) errorsBefore(position int) bool {
+	for i := position - 1; i >= 0 && t.content[i] != " " && t.content[i] != "\n"; i-- {
+		if t.characterColours[i] == incorrectKey || t.characterColours[i] == missedKey {
+			return true
+		}
+	}
+	return false
+}
+
+// whether the confidence setting lets backspace remove the key before the cursor
+func (t *typing) canBackspace() bool {
+	switch t.confidence {
+	case confidenceMax:
+		return t.mustFixWord() && t.withinWord()
+	case confidenceOn:
+		return t.withinWord()
+	}
+	return true
+}
+
+// whether the key before the cursor is part of the current word. extra keys are, but a space
+// or auto indentation isn't
+func (t *typing) withinWord() bool {
+	if t.extraKeys > 0 {
+		return true
+	}
+	return t.indentationBefore(t.position) == 0 && t.content[t.position-1] != " " && t.content[t.position-1] != "\n"
+}
+
+// whether stop on word is holding the cursor until a mistake in the current word is fixed,
+// which max confidence still lets the user do so the round can't get stuck
+func (t *typing) mustFixWord() bool {
+	return t.stopOnError == stopOnErrorWord && t.wordHasErrors()
+}
+
+// clears any extra keys typed after the end of a word, putting back the colour they replaced
+func (t *typing) removeExtraKeys() {
+	if t.extraKeys == 0 {
+		return
+	}
+	t.extraKeys = 0
+	t.characterColours[t.position-1] = t.extraColour
+}
+
+// moves the extra keys of the word before the cursor into extraChars as the word is finished
+func (t *typing) keepExtraKeys() {
+	if t.extraKeys == 0 {
+		return
+	}
+	if t.extraRuns == nil {
+		t.extraRuns = map[int]extraRun{}
+	}
+	t.extraRuns[t.trimmedChars+t.position] = extraRun{keys: t.extraKeys, colour: t.extraColour}
+	t.extraChars += t.extraKeys
+	t.extraKeys = 0
+}
+
+// takes back the extra keys of a word when the space finishing it, at the cursor, is deleted.
+// returns false if there weren't any
+func (t *typing) restoreExtraKeys() bool {
+	run, ok := t.extraRuns[t.trimmedChars+t.position]
+	if !ok {
+		return false
+	}
+	delete(t.extraRuns, t.trimmedChars+t.position)
+	t.extraChars -= run.keys
+	t.extraKeys, t.extraColour = run.keys, run.colour
+	return true
+}
+
+// deletes back to the start of the current word. at the start of a word it deletes the
+// previous word instead, but only if that word has mistakes to fix
+func (t *typing) deleteWord() {
+	t.removeExtraKeys()
+	if t.position < len(t.content) && t.characterColours[t.position] == incorrectKey {
+		// stop on letter leaves a wrong key marked on the character under the cursor
+		t.characterColours[t.position] = defaultKey
+	}
+	start := t.position - t.indentationBefore(t.position)
+	if start > 0 && (t.content[start-1] == " " || t.content[start-1] == "\n") {
+		_, extras := t.extraRuns[t.trimmedChars+start-1]
+		if t.confidence == confidenceOn || !(extras || t.errorsBefore(start-1)) {
+			return
+		}
+		// undo the space or line break, and any auto indentation after it
+		for i := start - 1; i < t.position; i++ {
+			t.characterColours[i] = defaultKey
+		}
+		t.position = start - 1
+		// the word's extra keys are deleted along with it
+		if t.restoreExtraKeys() {
+			t.extraKeys = 0
+		}
+	}
+	for t.position > 0 && t.content[t.position-1] != " " && t.content[t.position-1] != "\n" {
+		t.characterColours[t.position-1] = defaultKey
+		t.position -= 1
+	}
+}
+
+// records the key press at the cursor in the round's key stats, along with how long it took
+func (t *typing) recordKey(correct bool) {
+	latency := t.keyInterval()
+	bigram := ""
+	if t.position > 0 && t.content[t.position-1] != " " && t.content[t.position-1] != "\n" {
+		bigram = t.content[t.position-1] + t.content[t.position]
+	}
+	t.keys.record(t.content[t.position], bigram, correct, latency)
+}
+
+// returns the time since the previous key press, 0 for the first, and keeps it for consistency
+func (t *typing) keyInterval() time.Duration {
+	now := time.Now()
+	var interval time.Duration
+	if !t.lastKey.IsZero() {
+		interval = now.Sub(t.lastKey)
+		if interval <= maxKeyLatency {
+			// a long gap is a pause rather than part of the typing rhythm
+			t.intervals = append(t.intervals, interval)
+		}
+	}
+	t.lastKey = now
+	return interval
+}
+
+// fails the round if the last key press broke one of its failure conditions
+func (t *typing) checkFailure() {
+	if t.suddenDeath && t.keyPresses() > t.correctKeyPresses() {
+		t.time.fail("incorrect key in sudden death")
+		return
+	}
+	if t.accuracyFloor > 0 && t.keyPresses() >= accuracyGrace && t.accuracy() < float64(t.accuracyFloor) {
+		t.time.fail(fmt.Sprintf("accuracy fell below %d%%", t.accuracyFloor))
+	}
+}
+
 func (t typing) viewTypingTab(designStyles colourTheme) string {
 	output := ""
-	count := 0
-	lineCount := 0
-	for pos, val := range t.characterColours {
-		if lineCount < 3 {
-			switch val {
-			case defaultKey:
-				output += designStyles.typeTextDefault.Render(string(t.content[pos]))
-			case correctKey:
-				output += designStyles.typeTextCorrect.Render(string(t.content[pos]))
-			case incorrectKey:
-				output += designStyles.typeTextIncorrect.Render(string(t.content[pos]))
+	// show the line being typed and the ones after it
+	first := t.lineOf(t.position)
+	if t.gameMode == gameModeZen {
+		// zen has nothing ahead of the cursor so show the lines leading up to it
+		first = max(0, first-t.visibleLines()+1)
+		if len(t.content) == 0 {
+			output += designStyles.normalText.Render("Type anything, ESC to finish")
+		}
+	}
+	review := t.blind && t.time.isFinished()
+	if review {
+		// review the lines typed now the mistakes can be shown
+		first = max(0, first-blindReviewLines+1)
+	}
+	last := min(first+t.visibleLines(), len(t.lineStarts))
+	if review {
+		last = t.lineOf(t.position) + 1
+	}
+	if t.tape && !review {
+		output += t.viewTape(designStyles) + "\n"
+	} else {
+		for line := first; line < last; line++ {
+			start, end := t.lineBounds(line)
+			masked := t.lineMasked(line)
+			for pos := start; pos < end; pos++ {
+				output += t.viewChar(pos, masked, designStyles)
 			}
-			if t.content[pos] == ' ' && t.gameMode == gameModeCountdown {
-				count += 1
-				// check if the colour is default or not - if not default we don't want this line and remove it
-				if count%15 == 0 {
-					output = output[:len(output)-1]
-					output += "\n"
-					lineCount += 1
-					if t.characterColours[pos] != defaultKey {
-						output = ""
-						lineCount = 0
-					}
-				}
+			output += "\n"
+		}
+	}
+
+	output = output + "\n\n" + t.time.displayTimer(designStyles)
+	if t.time.isFinished() {
+		output += "\n\n" + t.result.viewResult(designStyles)
+		output += "\n" + t.viewAccuracyBreakdown(designStyles)
+		if chart := viewChart(t.samples, designStyles); chart != "" {
+			output += "\n\n" + chart
+		}
+	}
+	return output
+}
+
+// renders the character at a position, hiding it if it's still to be typed on a masked line
+func (t typing) viewChar(pos int, masked bool, designStyles colourTheme) string {
+	if masked && t.characterColours[pos] == defaultKey {
+		return t.maskChar(pos, designStyles)
+	}
+	return t.renderChar(pos, designStyles)
+}
+
+// renders the character at a position coloured by how it was typed
+func (t typing) renderChar(pos int, designStyles colourTheme) string {
+	char := t.content[pos]
+	if char == "\n" {
+		// show where enter needs pressing
+		char = "↵"
+	}
+	style := designStyles.typeTextDefault
+	switch colour := t.characterColours[pos]; {
+	case t.blind && !t.time.isFinished() && colour != defaultKey:
+		// typed characters all look correct so the screen shows how far you've got but gives no
+		// feedback on mistakes
+		style = designStyles.typeTextCorrect
+	case colour == correctKey:
+		style = designStyles.typeTextCorrect
+	case colour == incorrectKey:
+		style = designStyles.typeTextIncorrect
+	case colour == missedKey:
+		style = designStyles.typeTextDefault.Strikethrough(true)
+	}
+	if pos == t.ghostPosition() {
+		// the ghost's caret, reversed as wrong keys are already underlined
+		style = style.Reverse(true)
+	}
+	return style.Render(char)
+}
 
+func (t typing) visibleLines() int {
+	if t.gameMode == gameModeCode {
+		return codeVisibleLines
+	}
+	return visibleLines
+}
+
+// splits the content into lines no wider than maxLineWidth cells, breaking after spaces where
+// possible and always after newlines. wide characters (e.g. CJK) take up two cells
+func (t *typing) wrapContent() {
+	t.lineStarts = []int{0}
+	start, lastSpace, width := 0, -1, 0
+	for i, char := range t.content {
+		switch char {
+		case "\n":
+			if i+1 < len(t.content) {
+				start = i + 1
+				t.lineStarts = append(t.lineStarts, start)
+				width = 0
+			}
+			continue
+		case " ":
+			// spaces can hang off the end of a line so never cause a wrap
+			lastSpace = i
+			width += 1
+			continue
+		}
+		charWidth := uniseg.StringWidth(char)
+		if width+charWidth > maxLineWidth {
+			if lastSpace >= start {
+				start = lastSpace + 1
+			} else {
+				// a single word longer than a line has to be split
+				start = i
+			}
+			t.lineStarts = append(t.lineStarts, start)
+			width = 0
+			for _, carried := range t.content[start:i] {
+				width += uniseg.StringWidth(carried)
 			}
 		}
+		width += charWidth
+	}
+}
 
+// returns which wrapped line a position is on
+func (t typing) lineOf(position int) int {
+	return sort.SearchInts(t.lineStarts, position+1) - 1
+}
+
+// returns the first position of a line and the position just after its end
+func (t typing) lineBounds(line int) (int, int) {
+	if line+1 < len(t.lineStarts) {
+		return t.lineStarts[line], t.lineStarts[line+1]
 	}
+	return t.lineStarts[line], len(t.content)
+}
 
-	output = output + "\n\n\n" + t.time.displayTimer(designStyles)
-	return output
+// stops the round and returns its result, ok is false when it had already been finished.
+// err is any failure to save what was recorded about the round
+func (t *typing) finishRound() (result roundResult, ok bool, err error) {
+	if t.time.isFinished() {
+		return roundResult{}, false, nil
+	}
+	t.time.stopTimer()
+	t.finishSamples()
+	keyHistory.add(&t.keys)
+	t.result = t.buildResult()
+	log := t.roundLog()
+	err = errors.Join(keyHistory.save(), saveRoundLog(log), saveBestLog(t.bestKey(), log))
+	return t.result, true, err
 }
 
 func (t *typing) roundFinished() bool {
+	if t.time.isFailed() {
+		return true
+	}
 	// check what type of timer and return true if the round has been finished
 	switch v := t.time.(type) {
 	case *timerDown:
@@ -188,8 +740,12 @@ func (t *typing) roundFinished() bool {
 			return true
 		}
 	case *timerUp:
+		if t.gameMode == gameModeZen {
+			return t.ended
+		}
 		if t.position == len(t.content)-1 {
-			return true
+			// in stop on word the last word has to be right as well
+			return t.stopOnError != stopOnErrorWord || !t.wordHasErrors()
 		}
 	}
 	return false
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	wordListDirName   = "wordlists"
+	randomWordsSource = "Random Words"
+)
+
+// WordSource supplies the words that generated rounds are built from.
+// New vocabularies can be added by implementing this and calling registerWordSource
+type WordSource interface {
+	Name() string
+	Description() string
+	NextWord() string
+	NextChunk(n int) []string
+}
+
+// wordList is a WordSource which picks uniformly at random from a fixed list of words
+type wordList struct {
+	name        string
+	description string
+	words       []string
+}
+
+// every source selectable from the settings tab, the first one is the default
+var wordSources = []WordSource{&languageWords{}, &markovWords{}}
+
+// creates a word list, dropping any duplicate words so each word is equally likely
+func newWordList(name string, description string, words []string) *wordList {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, w := range words {
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		unique = append(unique, w)
+	}
+	return &wordList{name: name, description: description, words: unique}
+}
+
+func (w *wordList) Name() string {
+	return w.name
+}
+
+func (w *wordList) Description() string {
+	return w.description
+}
+
+func (w *wordList) NextWord() string {
+	return w.words[rand.Intn(len(w.words))]
+}
+
+func (w *wordList) NextChunk(n int) []string {
+	return nextChunk(w, n)
+}
+
+// returns the next n words from a word source, for sources with no better way to pick a chunk
+func nextChunk(ws WordSource, n int) []string {
+	res := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		res = append(res, ws.NextWord())
+	}
+	return res
+}
+
+// adds a word source to the list offered in settings, replacing any source with the same name
+func registerWordSource(ws WordSource) {
+	wordSources = register(wordSources, ws, WordSource.Name)
+}
+
+// returns the source with the given name, or the default source if there isn't one
+func findWordSource(name string) WordSource {
+	if ws, ok := findNamed(wordSources, name, WordSource.Name); ok {
+		return ws
+	}
+	return wordSources[0]
+}
+
+// registers every .txt file in the wordlists config directory as a word source
+// files are plain text with words separated by whitespace, named after the file
+func loadWordListFiles() error {
+	dir, err := configPath(wordListDirName)
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		words := strings.Fields(string(data))
+		if len(words) == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		registerWordSource(newWordList(name, "Custom word list from "+filepath.Base(path), words))
+	}
+	return nil
+}